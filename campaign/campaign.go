@@ -0,0 +1,259 @@
+package campaign
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+)
+
+const Version = "1.00"
+
+var (
+	ErrUnsupportedVersion = errors.New("campaign: unsupported version")
+	ErrInvalidEntry       = errors.New("campaign: invalid entry")
+)
+
+type (
+	Header struct {
+		Version       [4]byte
+		Name          [256]byte
+		ScenarioCount int32
+	}
+
+	EntryInfo struct {
+		Size     int32
+		Offset   int32
+		Name     [255]byte
+		FileName [257]byte
+	}
+
+	// Entry is a scenario of a campaign.
+	Entry struct {
+		EntryInfo
+
+		rd io.ReaderAt
+	}
+
+	Reader struct {
+		Header
+
+		Entries []*Entry
+
+		rd io.ReaderAt // the underlying reader
+	}
+
+	// File is a scenario to be written with Encode.
+	File struct {
+		Name     string
+		FileName string // e.g. "hastings.scx"
+		Data     []byte
+	}
+)
+
+// cstring returns the string up to the first NUL byte.
+func cstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func Open(filename string) (*Reader, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	r, err := NewReader(fh, fi.Size())
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func NewReader(rd io.ReaderAt, size int64) (*Reader, error) {
+	r := &Reader{rd: rd}
+	sr := io.NewSectionReader(rd, 0, size)
+	if err := binary.Read(sr, binary.LittleEndian, &r.Header); err != nil {
+		return nil, fmt.Errorf("campaign: failed to read header: %w", err)
+	}
+	if r.VersionString() != Version {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, r.Header.Version[:])
+	}
+	if r.ScenarioCount < 0 || int64(r.ScenarioCount)*int64(binary.Size(EntryInfo{})) > size {
+		return nil, fmt.Errorf("%w: %d scenarios", ErrInvalidEntry, r.ScenarioCount)
+	}
+
+	entries := make([]Entry, r.ScenarioCount)
+	r.Entries = make([]*Entry, r.ScenarioCount)
+	for i := range entries {
+		e := &entries[i]
+		e.rd = rd
+		if err := binary.Read(sr, binary.LittleEndian, &e.EntryInfo); err != nil {
+			return nil, fmt.Errorf("%w: %d: %w", ErrInvalidEntry, i, err)
+		}
+		if e.Size < 0 || e.Offset < 0 || int64(e.Offset)+int64(e.Size) > size {
+			return nil, fmt.Errorf("%w: %d: %d bytes at %d", ErrInvalidEntry, i, e.Size, e.Offset)
+		}
+		r.Entries[i] = e
+	}
+	return r, nil
+}
+
+// Close closes the underlying reader if it implements io.Closer, otherwise it's a noop.
+func (r *Reader) Close() error {
+	if c, ok := r.rd.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// VersionString returns the version, e.g. "1.00".
+func (h *Header) VersionString() string {
+	return cstring(h.Version[:])
+}
+
+// String returns the name of the campaign.
+func (h *Header) String() string {
+	return cstring(h.Name[:])
+}
+
+// Entry returns the entry with the given file name or nil.
+func (r *Reader) Entry(filename string) *Entry {
+	for _, e := range r.Entries {
+		if e.FileNameString() == filename {
+			return e
+		}
+	}
+	return nil
+}
+
+// Files reads all scenarios, e.g. to write a modified campaign with Encode.
+func (r *Reader) Files() ([]File, error) {
+	res := make([]File, len(r.Entries))
+	for i, e := range r.Entries {
+		data, err := e.Data()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = File{Name: e.String(), FileName: e.FileNameString(), Data: data}
+	}
+	return res, nil
+}
+
+// Extract writes the scenarios to the directory dir, using the base name
+// of their file names.
+func (r *Reader) Extract(dir string) error {
+	for i, e := range r.Entries {
+		name := filepath.Base(e.FileNameString())
+		if name == "." || name == string(filepath.Separator) {
+			return fmt.Errorf("%w: %d: invalid file name %q", ErrInvalidEntry, i, e.FileNameString())
+		}
+		data, err := e.Data()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the name of the scenario.
+func (e *Entry) String() string {
+	return cstring(e.Name[:])
+}
+
+// FileNameString returns the file name of the scenario.
+func (e *Entry) FileNameString() string {
+	return cstring(e.FileName[:])
+}
+
+func (e *Entry) Open() *io.SectionReader {
+	return io.NewSectionReader(e.rd, int64(e.Offset), int64(e.Size))
+}
+
+// Data returns the scenario file.
+func (e *Entry) Data() ([]byte, error) {
+	buf := make([]byte, e.Size)
+	if _, err := e.rd.ReadAt(buf, int64(e.Offset)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Scenario decodes the scenario.
+func (e *Entry) Scenario() (*scenario.Scenario, error) {
+	return scenario.Decode(e.Open())
+}
+
+// setString copies s to the fixed size field dst, leaving room for a NUL.
+func setString(dst []byte, s string) error {
+	if len(s) >= len(dst) {
+		return fmt.Errorf("%w: %q is too long", ErrInvalidEntry, s)
+	}
+	copy(dst, s)
+	return nil
+}
+
+// Encode writes a campaign with the given name.
+func Encode(w io.Writer, name string, files []File) error {
+	h := Header{ScenarioCount: int32(len(files))}
+	copy(h.Version[:], Version)
+	if err := setString(h.Name[:], name); err != nil {
+		return err
+	}
+
+	entries := make([]EntryInfo, len(files))
+	offset := binary.Size(h) + len(entries)*binary.Size(EntryInfo{})
+	for i, f := range files {
+		e := &entries[i]
+		e.Size = int32(len(f.Data))
+		e.Offset = int32(offset)
+		offset += len(f.Data)
+		if err := setString(e.Name[:], f.Name); err != nil {
+			return err
+		}
+		if err := setString(e.FileName[:], f.FileName); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, entries); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if _, err := w.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create writes a campaign to the file filename, see Encode.
+func Create(filename, name string, files []File) error {
+	fh, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := Encode(fh, name, files); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
@@ -0,0 +1,110 @@
+package campaign_test
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/campaign"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/norman.cpx holds two empty scenarios, Landing.scx (8x8) and
+// Hastings.scx (12x12).
+
+func TestOpen(t *testing.T) {
+	r, err := campaign.Open("./testdata/norman.cpx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+	assert.Equal(t, "1.00", r.VersionString())
+	assert.Equal(t, "Norman Conquest", r.String())
+	if !assert.Len(t, r.Entries, 2) {
+		return
+	}
+	assert.Equal(t, "Landing", r.Entries[0].String())
+	assert.Equal(t, "Hastings.scx", r.Entries[1].FileNameString())
+
+	e := r.Entry("Hastings.scx")
+	if assert.NotNil(t, e) {
+		s, err := e.Scenario()
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint32(12), s.Map.Width)
+		}
+	}
+	assert.Nil(t, r.Entry("York.scx"))
+}
+
+func TestRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("./testdata/norman.cpx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	r, err := campaign.NewReader(bytes.NewReader(data), int64(len(data)))
+	if !assert.NoError(t, err) {
+		return
+	}
+	files, err := r.Files()
+	if !assert.NoError(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	if assert.NoError(t, campaign.Encode(&buf, r.String(), files)) {
+		assert.Equal(t, data, buf.Bytes())
+	}
+}
+
+func TestExtract(t *testing.T) {
+	r, err := campaign.Open("./testdata/norman.cpx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+	dir := t.TempDir()
+	if !assert.NoError(t, r.Extract(dir)) {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Landing.scx"))
+	if assert.NoError(t, err) {
+		want, _ := r.Entries[0].Data()
+		assert.Equal(t, want, data)
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys, err := campaign.OpenFS("./testdata/norman.cpx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer fsys.Close()
+	assert.NoError(t, fstest.TestFS(fsys, "Hastings.scx", "Landing.scx"))
+
+	_, err = fsys.Open("York.scx")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	matches, err := fs.Glob(fsys, "*.scx")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"Hastings.scx", "Landing.scx"}, matches)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	data, err := os.ReadFile("./testdata/norman.cpx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = campaign.NewReader(bytes.NewReader(data), 300)
+	assert.ErrorIs(t, err, campaign.ErrInvalidEntry)
+
+	bad := bytes.Clone(data)
+	copy(bad, "2.00")
+	_, err = campaign.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.ErrorIs(t, err, campaign.ErrUnsupportedVersion)
+
+	long := string(make([]byte, 300))
+	assert.ErrorIs(t, campaign.Encode(&bytes.Buffer{}, long, nil), campaign.ErrInvalidEntry)
+}
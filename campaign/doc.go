@@ -0,0 +1,22 @@
+// Read and write campaign files (.cpn, .cpx).
+//
+// A campaign bundles several scenarios. The header is followed by one entry
+// per scenario and the scenario files, which are stored unchanged:
+//
+//	+--------+------+--------------+
+//	| Offset | Size | Name         |
+//	|--------|------|--------------|
+//	|      0 |  264 | Header       |
+//	|    264 |  520 | EntryInfo[0] |
+//	| ...                          |
+//	|        |      | EntryInfo[N] |
+//	|        |      | Scenario[0]  |
+//	| ...                          |
+//	|        |      | Scenario[N]  |
+//	+--------+------+--------------+
+//
+// Only the version 1.00 used by AoE, AoK and AoC is supported, not the
+// .aoe2campaign files of DE.
+//
+// FS provides an fs.FS view of a campaign with one file per scenario.
+package campaign
@@ -0,0 +1,159 @@
+package campaign
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS is a flat directory with one file per scenario, named by the file
+// name stored in the campaign.
+type FS struct {
+	rd *Reader
+
+	files map[string]*Entry
+	names []string // sorted
+}
+
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+
+	errIsDir = errors.New("is a directory")
+)
+
+func OpenFS(filename string) (*FS, error) {
+	rd, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewFS(rd), nil
+}
+
+// NewFS returns a view of the campaign. Scenarios without a valid file name
+// are left out, of duplicate names the first one is used.
+func NewFS(rd *Reader) *FS {
+	fsys := &FS{
+		rd:    rd,
+		files: make(map[string]*Entry),
+	}
+	for _, e := range rd.Entries {
+		name := e.FileNameString()
+		if _, found := fsys.files[name]; found || name == "." || !fs.ValidPath(name) || strings.Contains(name, "/") {
+			continue
+		}
+		fsys.files[name] = e
+		fsys.names = append(fsys.names, name)
+	}
+	sort.Strings(fsys.names)
+	return fsys
+}
+
+// Close closes the underlying reader.
+func (fsys *FS) Close() error {
+	return fsys.rd.Close()
+}
+
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &rootFile{fsys: fsys}, nil
+	}
+	e, found := fsys.files[name]
+	if !found {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &file{SectionReader: e.Open(), info: fileInfo{name, int64(e.Size), 0o444}}, nil
+}
+
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	e, found := fsys.files[name]
+	if !found {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.Data()
+}
+
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return fsys.entries(), nil
+}
+
+func (fsys *FS) entries() []fs.DirEntry {
+	res := make([]fs.DirEntry, len(fsys.names))
+	for i, name := range fsys.names {
+		res[i] = fs.FileInfoToDirEntry(fileInfo{name, int64(fsys.files[name].Size), 0o444})
+	}
+	return res
+}
+
+type file struct {
+	*io.SectionReader
+
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// this struct represents the root entry.
+type rootFile struct {
+	fsys *FS
+
+	entries []fs.DirEntry // not yet returned by ReadDir
+	read    bool
+}
+
+var _ fs.ReadDirFile = (*rootFile)(nil)
+
+func (rf *rootFile) Stat() (fs.FileInfo, error) {
+	return fileInfo{".", 0, fs.ModeDir | 0o555}, nil
+}
+
+func (rf *rootFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: errIsDir}
+}
+
+func (rf *rootFile) Close() error { return nil }
+
+func (rf *rootFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !rf.read {
+		rf.entries, rf.read = rf.fsys.entries(), true
+	}
+	if n <= 0 {
+		res := rf.entries
+		rf.entries = nil
+		return res, nil
+	}
+	if len(rf.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rf.entries))
+	res := rf.entries[:n]
+	rf.entries = rf.entries[n:]
+	return res, nil
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
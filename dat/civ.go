@@ -0,0 +1,33 @@
+package dat
+
+type (
+	// Civ is a civilization. Every civilization has its own copy of all
+	// units.
+	Civ struct {
+		PlayerType    int8
+		Name          []byte `bin:"len=$CivNameSize"`
+		ResourceCount uint16
+		TechTreeID    int16     // effect ID
+		TeamBonusID   int16     `bin:"ver=AoK-"` // effect ID
+		Name2         []byte    `bin:"len=$CivNameSize,ver=SWGB-CC"`
+		UniqueUnits   [4]int16  `bin:"ver=SWGB-CC"`       // unique units and techs
+		Resources     []float32 `bin:"len=ResourceCount"` // starting resources
+		IconSet       int8      `bin:"ver=AoK-"`
+		UnitCount     uint16
+		UnitPointers  []int32 `bin:"len=UnitCount"`
+		Units         []*Unit `bin:"ptrs=UnitPointers"`
+	}
+)
+
+// String returns the name of the civilization.
+func (c *Civ) String() string {
+	return cstring(c.Name)
+}
+
+// Unit returns the unit with the given ID or nil.
+func (c *Civ) Unit(id int) *Unit {
+	if id < 0 || id >= len(c.Units) {
+		return nil
+	}
+	return c.Units[id]
+}
@@ -0,0 +1,256 @@
+package dat
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("dat: unsupported version")
+	ErrVersionMismatch    = errors.New("dat: version mismatch")
+	ErrAmbiguousVersion   = errors.New("dat: ambiguous version")
+	ErrTrailingData       = errors.New("dat: trailing data")
+	ErrInvalidLength      = codec.ErrInvalidLength
+)
+
+type File struct {
+	Version     [8]byte
+	GameVersion Version `bin:"-"` // the edition the file was decoded as
+
+	// SWGB and CC store the number of civilizations up front.
+	SWGBCivCount uint16   `bin:"ver=SWGB-CC"`
+	SWGBUnknown  [4]int32 `bin:"ver=SWGB-CC"`
+
+	TerrainRestrictionCount    uint16
+	TerrainsUsed1              uint16               `bin:"var=TerrainsUsed"`
+	FloatPtrTerrainTables      []int32              `bin:"len=TerrainRestrictionCount"`
+	TerrainPassGraphicPointers []int32              `bin:"len=TerrainRestrictionCount,ver=AoK-"`
+	TerrainRestrictions        []TerrainRestriction `bin:"len=TerrainRestrictionCount"`
+
+	PlayerColorCount uint16
+	PlayerColors     []PlayerColor `bin:"len=PlayerColorCount"`
+
+	SoundCount uint16
+	Sounds     []Sound `bin:"len=SoundCount"`
+
+	GraphicCount    uint16
+	GraphicPointers []int32    `bin:"len=GraphicCount"`
+	Graphics        []*Graphic `bin:"ptrs=GraphicPointers"` // nil if unused
+
+	TerrainBlock TerrainBlock
+	RandomMaps   RandomMaps
+
+	EffectCount uint32
+	Effects     []Effect `bin:"len=EffectCount"`
+
+	UnitLineCount uint16     `bin:"ver=SWGB-CC"`
+	UnitLines     []UnitLine `bin:"len=UnitLineCount,ver=SWGB-CC"`
+
+	UnitHeaderCount uint32       `bin:"ver=AoK-"`
+	UnitHeaders     []UnitHeader `bin:"len=UnitHeaderCount,ver=AoK-"`
+
+	CivCount uint16
+	Civs     []Civ `bin:"len=CivCount"`
+
+	SWGBUnknown7 int8 `bin:"ver=SWGB-CC"`
+
+	TechCount uint16
+	Techs     []Tech `bin:"len=TechCount"`
+
+	SWGBUnknown8 int8 `bin:"ver=SWGB-CC"`
+
+	TimeSlice         int32 `bin:"ver=AoK-"`
+	UnitKillRate      int32 `bin:"ver=AoK-"`
+	UnitKillTotal     int32 `bin:"ver=AoK-"`
+	UnitHitPointRate  int32 `bin:"ver=AoK-"`
+	UnitHitPointTotal int32 `bin:"ver=AoK-"`
+	RazingKillRate    int32 `bin:"ver=AoK-"`
+	RazingKillTotal   int32 `bin:"ver=AoK-"`
+
+	TechTree TechTree `bin:"ver=AoK-"`
+}
+
+// cstring returns the string up to the first NUL byte.
+func cstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// decompress returns a reader for the uncompressed data. The game uses raw
+// deflate, zlib streams and uncompressed data are accepted as well.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case string(head) == "VER ":
+		return br, nil
+	case head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0:
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// Decode reads a compressed DAT file. The version strings are shared by
+// several editions ("VER 3.7" by AoE and RoR, "VER 5.7" by AoK, AoC and HD,
+// "VER 5.9" by SWGB and CC), so the file is decoded with each of them and
+// the edition whose layout matches the whole file is used.
+// ErrAmbiguousVersion is returned if several editions match, e.g. for
+// editions with the same layout; DecodeVersion reads those files.
+func Decode(r io.Reader) (*File, error) {
+	return DecodeVersion(r, VersionUnknown)
+}
+
+// DecodeVersion reads a compressed DAT file of the given edition. If version
+// is VersionUnknown it is detected like Decode does. ErrVersionMismatch is
+// returned if the version string of the file does not belong to version.
+func DecodeVersion(r io.Reader, version Version) (*File, error) {
+	rd, err := decompress(r)
+	if err != nil {
+		return nil, fmt.Errorf("dat: %w", err)
+	}
+
+	f := File{GameVersion: version}
+	br := bufio.NewReader(rd)
+	if _, err := io.ReadFull(br, f.Version[:]); err != nil {
+		return nil, fmt.Errorf("dat: failed to read version: %w", err)
+	}
+	if version.IsValid() {
+		if _, err := f.version(); err != nil {
+			return nil, err
+		}
+		if err := f.decode(br); err != nil {
+			return nil, err
+		}
+		return &f, nil
+	}
+
+	candidates := versionsOf(f.VersionString())
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedVersion, f.VersionString())
+	}
+	return detect(br, f.Version, candidates)
+}
+
+// detect decodes the data after the version string with each of the
+// candidate editions, see Decode. If none of them matches, the error of the
+// edition which read the most data is returned.
+func detect(r io.Reader, tag [8]byte, candidates []Version) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("dat: %w", err)
+	}
+	var res []*File
+	var bestErr error
+	bestRead := -1
+	for _, v := range candidates {
+		rd := bytes.NewReader(data)
+		f := &File{Version: tag, GameVersion: v}
+		err := f.decode(rd)
+		if err == nil && rd.Len() > 0 {
+			err = fmt.Errorf("%w: %s leaves %d bytes", ErrTrailingData, v, rd.Len())
+		}
+		if err == nil {
+			res = append(res, f)
+		} else if read := len(data) - rd.Len(); read > bestRead {
+			bestErr, bestRead = err, read
+		}
+	}
+	switch len(res) {
+	case 0:
+		return nil, bestErr
+	case 1:
+		return res[0], nil
+	}
+	names := make([]Version, len(res))
+	for i, f := range res {
+		names[i] = f.GameVersion
+	}
+	return nil, fmt.Errorf("%w: %q matches %v, use DecodeVersion", ErrAmbiguousVersion, cstring(tag[:]), names)
+}
+
+// decode reads the data after the version string as f.GameVersion.
+func (f *File) decode(r io.Reader) error {
+	d := codec.NewDecoder(r, f.GameVersion.config())
+	if err := d.DecodeFrom(f, 1); err != nil {
+		return fmt.Errorf("dat: %w", err)
+	}
+	return nil
+}
+
+// Open reads the DAT file filename.
+func Open(filename string) (*File, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return Decode(fh)
+}
+
+// VersionString returns the version, e.g. "VER 5.7".
+func (f *File) VersionString() string {
+	return cstring(f.Version[:])
+}
+
+// version returns the edition of f, see DecodeVersion. If GameVersion is
+// not set, the version string must belong to a single edition.
+func (f *File) version() (Version, error) {
+	if !f.GameVersion.IsValid() {
+		switch candidates := versionsOf(f.VersionString()); len(candidates) {
+		case 0:
+			return VersionUnknown, fmt.Errorf("%w: %q", ErrUnsupportedVersion, f.VersionString())
+		case 1:
+			return candidates[0], nil
+		default:
+			return VersionUnknown, fmt.Errorf("%w: %q is used by %v, set GameVersion", ErrAmbiguousVersion, f.VersionString(), candidates)
+		}
+	}
+	if f.GameVersion.Tag() != f.VersionString() {
+		return VersionUnknown, fmt.Errorf("%w: %s uses %q, got %q", ErrVersionMismatch, f.GameVersion, f.GameVersion.Tag(), f.VersionString())
+	}
+	return f.GameVersion, nil
+}
+
+// Graphic returns the graphic with the given ID or nil.
+func (f *File) Graphic(id int) *Graphic {
+	if id < 0 || id >= len(f.Graphics) {
+		return nil
+	}
+	return f.Graphics[id]
+}
+
+// Sound returns the sound with the given ID or nil.
+func (f *File) Sound(id int) *Sound {
+	for i := range f.Sounds {
+		if int(f.Sounds[i].ID) == id {
+			return &f.Sounds[i]
+		}
+	}
+	return nil
+}
+
+// Unit returns the unit with the given ID of a civilization or nil.
+func (f *File) Unit(civ, id int) *Unit {
+	if civ < 0 || civ >= len(f.Civs) {
+		return nil
+	}
+	return f.Civs[civ].Unit(id)
+}
+
+// StandingGraphic returns the first standing graphic of u or nil.
+func (f *File) StandingGraphic(u *Unit) *Graphic {
+	return f.Graphic(int(u.StandingGraphic))
+}
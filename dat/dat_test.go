@@ -0,0 +1,118 @@
+package dat_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"os"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/empires2_x1_p1.dat is a small synthetic AoC DAT file: one civ
+// with a tree (1) and an archer (4), three graphic slots (1 is unused) and
+// the 42 terrains required by the version.
+
+func TestOpen(t *testing.T) {
+	f, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "VER 5.7", f.VersionString())
+	assert.Len(t, f.TerrainRestrictions, 1)
+	assert.Equal(t, []float32{1, 0}, f.TerrainRestrictions[0].PassableBuildableDmgMultiplier)
+	assert.Len(t, f.PlayerColors, 2)
+	assert.Equal(t, int32(32), f.PlayerColors[1].PlayerColorBase)
+	assert.Len(t, f.TerrainBlock.Terrains, 42)
+	assert.Equal(t, "Grass 1", f.TerrainBlock.Terrains[0].String())
+	assert.Equal(t, drs.FileID(15001), f.TerrainBlock.Terrains[0].SLP)
+	assert.Len(t, f.RandomMaps.Maps, 1)
+	assert.Len(t, f.RandomMaps.Maps[0].MapUnits, 1)
+	assert.Equal(t, "Feudal Age", f.Effects[0].String())
+	assert.Len(t, f.UnitHeaders, 5)
+	assert.Len(t, f.UnitHeaders[4].Tasks, 1)
+	assert.Equal(t, "Feudal Age", f.Techs[0].String())
+	assert.Len(t, f.TechTree.Units, 1)
+
+	if assert.Len(t, f.Graphics, 3) {
+		assert.Nil(t, f.Graphic(1))
+		assert.Nil(t, f.Graphic(3))
+		g := f.Graphic(2)
+		assert.Equal(t, "FLAG", g.String())
+		assert.Equal(t, drs.InvalidFileID, g.SLP)
+		assert.Len(t, g.AngleSounds, 1)
+	}
+
+	tree := f.Unit(0, 1)
+	if assert.NotNil(t, tree) {
+		assert.Equal(t, "TREE", tree.String())
+		assert.Nil(t, tree.DeadFish)
+		assert.Nil(t, tree.Creatable)
+	}
+	assert.Nil(t, f.Unit(0, 2))
+	assert.Nil(t, f.Unit(1, 4))
+}
+
+func TestUnitGraphicAndName(t *testing.T) {
+	f, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	archer := f.Unit(0, 4)
+	if !assert.NotNil(t, archer) {
+		return
+	}
+	assert.Equal(t, int8(dat.UnitTypeCreatable), archer.Type)
+	assert.NotNil(t, archer.Combatant)
+	assert.NotNil(t, archer.Creatable)
+	assert.Nil(t, archer.Projectile)
+	assert.Nil(t, archer.Building)
+	assert.Len(t, archer.Combatant.Armors, 2)
+	assert.Equal(t, int16(35), archer.Creatable.TrainTime)
+
+	g := f.StandingGraphic(archer)
+	if assert.NotNil(t, g) {
+		assert.Equal(t, "ARCHR_STAND", g.String())
+		assert.Equal(t, "u_arc_stand", g.File())
+		assert.Equal(t, drs.FileID(12345), g.SLP)
+		assert.Equal(t, []dat.GraphicDelta{{GraphicID: 2, OffsetX: 3, OffsetY: -4, DisplayAngle: -1}}, g.Deltas)
+	}
+
+	strs := lang.Table{5000: "Archer"}
+	assert.Equal(t, uint32(5000), archer.NameID())
+	assert.Equal(t, "Archer", archer.LocalizedName(strs))
+	assert.Equal(t, "ARCHR", archer.LocalizedName(lang.Table{}))
+}
+
+func TestDecodeUncompressed(t *testing.T) {
+	fh, err := os.Open("./testdata/empires2_x1_p1.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	raw, err := io.ReadAll(flate.NewReader(fh))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := dat.Decode(bytes.NewReader(raw))
+	if assert.NoError(t, err) {
+		assert.Len(t, f.Civs, 1)
+	}
+
+	_, err = dat.Decode(bytes.NewReader(raw[:len(raw)/2]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// the Definitive Edition is not supported
+	copy(raw, "VER 7.8")
+	_, err = dat.Decode(bytes.NewReader(raw))
+	assert.ErrorIs(t, err, dat.ErrUnsupportedVersion)
+
+	raw[6] = 'X'
+	_, err = dat.Decode(bytes.NewReader(raw))
+	assert.ErrorIs(t, err, dat.ErrUnsupportedVersion)
+}
@@ -0,0 +1,139 @@
+package dat
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a difference between two DAT files.
+type Change struct {
+	Kind ChangeKind
+	Civ  int // index of the civilization, -1 for techs
+	ID   int // unit or tech ID
+
+	// Fields are the paths of the modified fields, e.g. "HitPoints" or
+	// "Creatable.TrainTime".
+	Fields []string
+}
+
+func (c Change) String() string {
+	what := fmt.Sprintf("unit %d (civ %d)", c.ID, c.Civ)
+	if c.Civ < 0 {
+		what = fmt.Sprintf("tech %d", c.ID)
+	}
+	if c.Kind == Modified {
+		return fmt.Sprintf("%s %s: %v", what, c.Kind, c.Fields)
+	}
+	return fmt.Sprintf("%s %s", what, c.Kind)
+}
+
+// diffFields appends the paths of the fields that differ between a and b.
+// Structs and pointers to structs are compared field by field, everything
+// else as a whole.
+func diffFields(res []string, prefix string, a, b reflect.Value) []string {
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				res = append(res, prefix)
+			}
+			return res
+		}
+		return diffFields(res, prefix, a.Elem(), b.Elem())
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name := sf.Name
+			switch {
+			case sf.Anonymous:
+				name = prefix // promoted fields
+			case prefix != "":
+				name = prefix + "." + name
+			}
+			res = diffFields(res, name, a.Field(i), b.Field(i))
+		}
+		return res
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		res = append(res, prefix)
+	}
+	return res
+}
+
+func diffItem(civ, id int, a, b any) *Change {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case av.IsNil() && bv.IsNil():
+		return nil
+	case av.IsNil():
+		return &Change{Kind: Added, Civ: civ, ID: id}
+	case bv.IsNil():
+		return &Change{Kind: Removed, Civ: civ, ID: id}
+	}
+	fields := diffFields(nil, "", av, bv)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &Change{Kind: Modified, Civ: civ, ID: id, Fields: fields}
+}
+
+// Diff compares the units of every civilization by unit ID and the techs
+// by tech ID. Unit changes come first, ordered by civilization and ID. A
+// civilization only present in one of the files counts as all its units
+// added or removed.
+func Diff(a, b *File) []Change {
+	var res []Change
+	add := func(c *Change) {
+		if c != nil {
+			res = append(res, *c)
+		}
+	}
+
+	for civ := 0; civ < max(len(a.Civs), len(b.Civs)); civ++ {
+		n := 0
+		if civ < len(a.Civs) {
+			n = len(a.Civs[civ].Units)
+		}
+		if civ < len(b.Civs) {
+			n = max(n, len(b.Civs[civ].Units))
+		}
+		for id := 0; id < n; id++ {
+			add(diffItem(civ, id, a.Unit(civ, id), b.Unit(civ, id)))
+		}
+	}
+
+	tech := func(f *File, id int) *Tech {
+		if id < len(f.Techs) {
+			return &f.Techs[id]
+		}
+		return nil
+	}
+	for id := 0; id < max(len(a.Techs), len(b.Techs)); id++ {
+		add(diffItem(-1, id, tech(a, id), tech(b, id)))
+	}
+	return res
+}
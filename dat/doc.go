@@ -0,0 +1,42 @@
+// Read and write genie DAT files (empires2_x1_p1.dat).
+//
+// The DAT file contains the game data: terrain tables, player colors,
+// sounds, graphics, terrains, random maps, technology effects, civilizations
+// with their units, researches and the technology tree.
+//
+// The file is compressed with deflate. The uncompressed data is a sequence of
+// little endian values without any padding, its layout is described by the
+// structs of this package. Struct tags (`bin:"..."`) describe how fields
+// depend on each other:
+//
+//	len=Field   the length of a slice or string is stored in Field
+//	len=$Var    the length is a value defined by the file (see below)
+//	ptrs=Field  slice of pointers, element i exists if Field[i] != 0
+//	if=Expr     the field only exists if Expr holds, e.g. Type>=20&Type!=90
+//	var=Var     store the value of this field as $Var
+//	ver=Ranges  the field only exists in the given editions, e.g. AoK- (AoK
+//	            and later), -RoR, SWGB-CC or -AoC|HD-
+//
+// The version string at the start of the file is shared by several
+// editions (see Version), Decode tries each of them and DecodeVersion reads
+// a given one. Values that depend on the edition but are not stored in the
+// file, like the number of terrains, are predefined variables. The Definitive
+// Edition is not supported.
+//
+// When writing, fields referenced by len and ptrs are updated from the
+// slices, so only the slices need to be modified.
+//
+// Round trips are verified for a synthetic AoC file and for a file of every
+// supported edition written field by field after genieutils: EncodeRaw
+// reproduces their uncompressed data byte for byte. The stock files of the
+// game cannot be distributed, so they are only checked if GENIE_TESTDATA
+// points to them; the round trip of stock files is not guaranteed, and
+// Definitive Edition files cannot be read at all. Encode compresses with
+// Go's deflate, the compressed bytes differ from the game's.
+//
+// String and graphic references point into other files: Graphic.SLP and
+// Terrain.SLP are DRS file IDs (see package drs), the LanguageDLL* fields
+// are string IDs (see package lang).
+//
+// Layout and naming follow genieutils (https://github.com/sandsmark/genieutils).
+package dat
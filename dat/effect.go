@@ -0,0 +1,26 @@
+package dat
+
+type (
+	// EffectCommand modifies the game state, its parameters depend on the
+	// type, e.g. type 0 sets attribute B of unit A to D.
+	EffectCommand struct {
+		Type uint8
+		A    int16
+		B    int16
+		C    int16
+		D    float32
+	}
+
+	// Effect is a list of commands applied by technologies, civilization
+	// bonuses and triggers.
+	Effect struct {
+		Name         []byte `bin:"len=$EffectNameSize"`
+		CommandCount uint16
+		Commands     []EffectCommand `bin:"len=CommandCount"`
+	}
+)
+
+// String returns the name of the effect.
+func (e *Effect) String() string {
+	return cstring(e.Name)
+}
@@ -0,0 +1,58 @@
+package dat
+
+import (
+	"bufio"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+)
+
+var (
+	ErrLengthMismatch = codec.ErrLengthMismatch
+)
+
+// Encode writes f compressed with deflate, like the game does. Count and
+// pointer fields are updated to match the slices before they are written,
+// so units, graphics etc. can be added by appending to the slices. Only the
+// uncompressed data matches the decoded file, see EncodeRaw.
+func Encode(w io.Writer, f *File) error {
+	zw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := EncodeRaw(zw, f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// EncodeRaw writes f without compression. For the files tested by this
+// package the data is identical to the decoded one, see the package
+// documentation for the scope of this guarantee.
+func EncodeRaw(w io.Writer, f *File) error {
+	version, err := f.version()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := codec.NewEncoder(bw, version.config()).Encode(f); err != nil {
+		return fmt.Errorf("dat: %w", err)
+	}
+	return bw.Flush()
+}
+
+// Create writes f to the file filename, see Encode.
+func Create(filename string, f *File) error {
+	fh, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := Encode(fh, f); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
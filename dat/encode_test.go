@@ -0,0 +1,152 @@
+package dat_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"os"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readRaw(t *testing.T, filename string) []byte {
+	t.Helper()
+	fh, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	raw, err := io.ReadAll(flate.NewReader(fh))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestRoundTrip(t *testing.T) {
+	raw := readRaw(t, "./testdata/empires2_x1_p1.dat")
+	f, err := dat.Decode(bytes.NewReader(raw))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if assert.NoError(t, dat.EncodeRaw(&buf, f)) {
+		assert.Equal(t, raw, buf.Bytes())
+	}
+
+	buf.Reset()
+	if assert.NoError(t, dat.Encode(&buf, f)) {
+		f2, err := dat.Decode(&buf)
+		if assert.NoError(t, err) {
+			assert.Equal(t, f, f2)
+		}
+	}
+}
+
+// TestRoundTripGameFiles decodes and encodes the DAT files of the game, see
+// testutil.GameFile.
+func TestRoundTripGameFiles(t *testing.T) {
+	for name, version := range map[string]dat.Version{
+		"empires.dat":        dat.VersionRoR,
+		"empires2.dat":       dat.VersionAoK,
+		"empires2_x1.dat":    dat.VersionAoC,
+		"empires2_x1_p1.dat": dat.VersionHD,
+		"genie.dat":          dat.VersionSWGB,
+		"genie_x1.dat":       dat.VersionCC,
+	} {
+		t.Run(name, func(t *testing.T) {
+			filename := testutil.GameFile(t, name)
+			fh, err := os.Open(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fh.Close()
+			f, err := dat.DecodeVersion(fh, version)
+			if !assert.NoError(t, err) {
+				return
+			}
+			raw := readRaw(t, filename)
+			var buf bytes.Buffer
+			if assert.NoError(t, dat.EncodeRaw(&buf, f)) {
+				assert.True(t, bytes.Equal(raw, buf.Bytes()), "the encoded data differs")
+			}
+		})
+	}
+}
+
+func TestEncodeSyncsCounts(t *testing.T) {
+	f, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	civ := &f.Civs[0]
+	civ.Units = append(civ.Units, &dat.Unit{Type: dat.UnitTypeBuilding, ID: 5, Name: "HOUSE\x00"})
+	f.Graphics[0] = nil
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, dat.Encode(&buf, f)) {
+		return
+	}
+	assert.Equal(t, uint16(6), civ.UnitCount)
+
+	f2, err := dat.Decode(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	house := f2.Unit(0, 5)
+	if assert.NotNil(t, house) {
+		assert.Equal(t, "HOUSE", house.String())
+		assert.Equal(t, uint16(6), house.NameLength)
+		assert.NotNil(t, house.Building)
+		assert.NotNil(t, house.Creatable)
+		assert.Nil(t, house.Projectile)
+	}
+	assert.Nil(t, f2.Graphic(0))
+	assert.NotNil(t, f2.Graphic(2))
+}
+
+func TestEncodeErrors(t *testing.T) {
+	f, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	f.TerrainBlock.Terrains = f.TerrainBlock.Terrains[:10]
+	assert.ErrorIs(t, dat.Encode(io.Discard, f), dat.ErrLengthMismatch)
+
+	f.Version[6] = '9'
+	assert.ErrorIs(t, dat.Encode(io.Discard, f), dat.ErrVersionMismatch)
+
+	f.GameVersion = dat.VersionUnknown
+	f.Version[6] = 'X'
+	assert.ErrorIs(t, dat.Encode(io.Discard, f), dat.ErrUnsupportedVersion)
+}
+
+func TestDiff(t *testing.T) {
+	a, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	b, err := dat.Open("./testdata/empires2_x1_p1.dat")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, dat.Diff(a, b))
+
+	b.Unit(0, 4).HitPoints = 35
+	b.Unit(0, 4).Creatable.TrainTime = 30
+	b.Civs[0].Units[1] = nil
+	b.Civs[0].Units = append(b.Civs[0].Units, &dat.Unit{ID: 5})
+	b.Techs[0].ResearchTime = 100
+
+	assert.Equal(t, []dat.Change{
+		{Kind: dat.Removed, Civ: 0, ID: 1},
+		{Kind: dat.Modified, Civ: 0, ID: 4, Fields: []string{"HitPoints", "Creatable.TrainTime"}},
+		{Kind: dat.Added, Civ: 0, ID: 5},
+		{Kind: dat.Modified, Civ: -1, ID: 0, Fields: []string{"ResearchTime"}},
+	}, dat.Diff(a, b))
+}
@@ -0,0 +1,70 @@
+package dat
+
+import (
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+)
+
+type (
+	// GraphicDelta is an additional graphic drawn relative to its parent,
+	// e.g. the flag of a building.
+	GraphicDelta struct {
+		GraphicID    int16
+		Padding1     int16
+		SpritePtr    int32
+		OffsetX      int16
+		OffsetY      int16
+		DisplayAngle int16
+		Padding2     int16
+	}
+
+	// GraphicAngleSound assigns up to three sounds to frames of an angle.
+	GraphicAngleSound struct {
+		FrameNum  int16
+		SoundID   int16
+		FrameNum2 int16
+		SoundID2  int16
+		FrameNum3 int16
+		SoundID3  int16
+	}
+
+	// Graphic describes a sprite: the SLP, its animation and sounds.
+	Graphic struct {
+		Name     []byte     `bin:"len=$NameSize"`
+		FileName []byte     `bin:"len=$FileNameSize"`
+		SLP      drs.FileID // InvalidFileID if unused
+
+		IsLoaded             int8
+		OldColorFlag         int8
+		Layer                int8
+		PlayerColor          int8
+		Rainbow              int8
+		TransparentSelection int8
+
+		Coordinates     [4]int16 // bounding box: x1, y1, x2, y2
+		DeltaCount      uint16
+		SoundID         int16
+		AngleSoundsUsed int8
+		FrameCount      uint16
+		AngleCount      uint16
+		SpeedMultiplier float32
+		FrameDuration   float32
+		ReplayDelay     float32
+		SequenceType    int8
+		ID              int16
+		MirroringMode   int8
+		EditorFlag      int8 `bin:"ver=AoK-"`
+
+		Deltas      []GraphicDelta      `bin:"len=DeltaCount"`
+		AngleSounds []GraphicAngleSound `bin:"len=AngleCount,if=AngleSoundsUsed!=0"`
+	}
+)
+
+// String returns the name of the graphic.
+func (g *Graphic) String() string {
+	return cstring(g.Name)
+}
+
+// File returns the SLP file name without extension, e.g. "u_arc_archerA".
+func (g *Graphic) File() string {
+	return cstring(g.FileName)
+}
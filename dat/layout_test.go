@@ -0,0 +1,372 @@
+package dat_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// layout writes a DAT file field by field in the order of genieutils,
+// independently of the struct tags. It contains a player color, a graphic,
+// a civ with a building, a tech and for SWGB and CC a unit line.
+type layout struct {
+	bytes.Buffer
+	v dat.Version
+}
+
+func (w *layout) put(values ...any) {
+	for _, v := range values {
+		binary.Write(&w.Buffer, binary.LittleEndian, v)
+	}
+}
+
+func (w *layout) zeros(n int) {
+	w.Write(make([]byte, n))
+}
+
+func (w *layout) str(s string, n int) {
+	b := make([]byte, n)
+	copy(b, s)
+	w.Write(b)
+}
+
+// aok reports whether the edition is AoK or later.
+func (w *layout) aok() bool { return w.v >= dat.VersionAoK }
+
+func (w *layout) swgb() bool { return w.v == dat.VersionSWGB || w.v == dat.VersionCC }
+
+// sizes returns the number of terrains and the sizes of the terrain, graphic
+// and graphic file names.
+func (w *layout) sizes() (terrains, terrainName, name, fileName int) {
+	switch w.v {
+	case dat.VersionAoC:
+		return 42, 13, 21, 13
+	case dat.VersionSWGB, dat.VersionCC:
+		return 55, 17, 25, 25
+	case dat.VersionHD:
+		return 100, 13, 21, 13
+	}
+	return 32, 13, 21, 13
+}
+
+func (w *layout) file() []byte {
+	terrains, _, name, fileName := w.sizes()
+	w.str(w.v.Tag(), 8)
+	if w.swgb() {
+		w.put(uint16(1), [4]int32{1, 2, 3, 4})
+	}
+
+	// one terrain restriction for two terrains
+	w.put(uint16(1), uint16(2), int32(0))
+	if w.aok() {
+		w.put(int32(0))
+	}
+	w.put([2]float32{1, 0})
+	if w.aok() {
+		for i := 0; i < 2; i++ {
+			w.put([3]int32{-1, -1, -1})
+			if w.swgb() {
+				w.put(int32(1)) // replication amount
+			} else {
+				w.put(float32(1)) // walk sprite rate
+			}
+		}
+	}
+
+	w.put(uint16(1))
+	if w.aok() {
+		w.put(int32(1), int32(16), [7]int32{})
+	} else {
+		w.str("Blue", 30)
+		w.put(int16(1), int16(2), uint8(3), uint8(4))
+	}
+
+	w.put(uint16(0)) // sounds
+
+	w.put(uint16(1), int32(1))
+	w.str("ARCHR_STAND", name)
+	w.str("u_arc_stand", fileName)
+	w.put(int32(12345), [6]int8{}, [4]int16{}, uint16(0), int16(-1), int8(0))
+	w.put(uint16(10), uint16(8), float32(1), float32(0.1), float32(0), int8(0), int16(0), int8(0))
+	if w.aok() {
+		w.put(int8(0))
+	}
+
+	w.terrainBlock(terrains)
+	w.put(uint32(0), int32(0)) // random maps
+	w.put(uint32(0))           // effects
+
+	if w.swgb() {
+		w.put(uint16(1), int16(5), uint16(4))
+		w.WriteString("Line")
+		w.put(uint16(1), int16(0))
+	}
+	if w.aok() {
+		w.put(uint32(1), uint8(0))
+	}
+
+	w.put(uint16(1), int8(1))
+	w.str("Gaia", 20)
+	w.put(uint16(1), int16(-1))
+	if w.aok() {
+		w.put(int16(-1))
+	}
+	if w.swgb() {
+		w.str("Gaia2", 20)
+		w.put([4]int16{-1, -1, -1, -1})
+	}
+	w.put(float32(200))
+	if w.aok() {
+		w.put(int8(0))
+	}
+	w.put(uint16(1), int32(1))
+	w.building()
+
+	if w.swgb() {
+		w.put(int8(0))
+	}
+	w.put(uint16(1))
+	w.tech()
+	if w.swgb() {
+		w.put(int8(0))
+	}
+
+	if w.aok() {
+		w.put([7]int32{})
+		w.put([4]uint8{}, int32(7)) // empty tech tree
+	}
+	return w.Bytes()
+}
+
+func (w *layout) terrainBlock(terrains int) {
+	_, nameSize, _, _ := w.sizes()
+	w.put([6]int32{}, [19][3]int16{})
+	if w.aok() {
+		w.put(int16(0))
+	}
+	animation := func() {
+		w.put(int8(0), int16(0), int16(0), float32(0), float32(0), int16(0), int16(0), float32(0), int8(0), int8(0))
+	}
+	for i := 0; i < terrains; i++ {
+		w.put(int8(1), int8(0))
+		w.str("Grass", nameSize)
+		w.str("g_grs", nameSize)
+		w.put(int32(15000+i), int32(0), int32(-1))
+		if w.aok() {
+			w.put(int32(i), int32(0))
+		}
+		w.put([3]uint8{1, 2, 3})
+		if w.aok() {
+			w.put([2]uint8{})
+		}
+		w.put(int8(0), int8(0))
+		animation()
+		w.put([19][3]int16{}, int16(-1), [2]int16{1, 1})
+		w.zeros(2 * terrains) // borders
+		w.put([30]int16{}, [30]int16{}, [30]int8{}, int16(0), int16(0))
+	}
+	for i := 0; i < 16; i++ {
+		w.put(int8(0), int8(0))
+		w.zeros(2 * nameSize)
+		w.put([3]int32{}, [3]uint8{})
+		animation()
+		w.put([19][12][3]int16{}, [3]int16{})
+	}
+	w.put(int32(0), [6]float32{}, [14]int16{}, [2]int32{}, [3]int8{})
+	if w.aok() {
+		w.put([21]uint8{}, [157]int32{})
+	}
+}
+
+// building writes a house (type 80), it has every section except the
+// projectile one.
+func (w *layout) building() {
+	w.put(int8(dat.UnitTypeBuilding), uint16(6), int16(0), uint16(5000), uint16(6000), int16(3), int16(7))
+	if w.aok() {
+		w.put(int16(8))
+	}
+	w.put(int16(-1), int16(-1), int8(0), int16(900), float32(4), int8(0), [3]float32{}, int16(-1))
+	if w.aok() {
+		w.put(int16(-1))
+	}
+	w.put(int16(-1), int8(0), int8(0), int16(1), int8(0), int16(0), int8(1))
+	if w.aok() {
+		w.put(int8(0))
+	}
+	w.put([2]int16{}, [2]int16{}, [2]float32{}, int8(0), int8(0), int16(0), int8(0), int16(0), float32(0))
+	w.put(int8(0), int8(0), int8(0), int8(0), int8(0), float32(0), uint8(0))
+	if w.aok() {
+		w.put(int32(0), int32(0), int32(0), [4]int8{}, uint8(0), int8(0), int8(0), uint8(0), int8(0), int16(0))
+		w.put(int8(0), uint8(0), [3]float32{})
+	}
+	for i := 0; i < 3; i++ {
+		w.put(int16(-1), float32(0), int8(0))
+	}
+	w.put(uint8(0), int16(-1), int16(-1), int8(0), int8(0))
+	w.WriteString("HOUSE\x00")
+	if w.swgb() {
+		w.put(uint16(4))
+		w.WriteString("Hut\x00")
+		w.put(int16(5), int8(2))
+	}
+	w.put(int16(0))
+	if w.aok() {
+		w.put(int16(0))
+	}
+	w.put(float32(0)) // speed
+
+	// dead fish
+	w.put(int16(-1), int16(-1), float32(0), int8(0), int16(-1), uint8(0), float32(0), int8(0))
+	if w.aok() {
+		w.put([5]float32{})
+	}
+
+	// bird
+	w.put(int16(-1), float32(0), float32(0), [2]int16{}, int8(0), int16(-1), int16(-1), int8(0))
+	if !w.aok() {
+		w.put(uint16(0))
+	}
+
+	// combatant
+	if w.aok() {
+		w.put(int16(3))
+	} else {
+		w.put(uint8(3))
+	}
+	w.put(uint16(0), uint16(0), int16(-1), [3]float32{}, int16(-1), int16(0), int8(0), int16(0), [3]float32{}, int8(0), float32(0))
+	if w.aok() {
+		w.put(float32(0))
+	}
+	w.put(int16(-1), int16(0), int16(0), float32(0), float32(0))
+
+	// creatable
+	w.put([9]int16{}, int16(25), int16(-1), int8(0))
+	if w.aok() {
+		w.put(float32(0), float32(0), int8(0), int8(0), int32(0), float32(0), int8(0), [3]float32{}, int32(0), int32(0), int8(0))
+	}
+	w.put(int16(2))
+
+	// building
+	w.put(int16(-1))
+	if w.v >= dat.VersionAoC {
+		w.put(int16(-1))
+	}
+	w.put(int8(0), int16(0), int8(0), int16(-1), int16(-1), int16(-1), int16(-1))
+	if w.aok() {
+		w.put(int8(0))
+		for i := 0; i < 4; i++ {
+			w.put(int16(-1), [2]float32{})
+		}
+		w.put(int16(-1), int16(-1), int16(-1))
+	}
+	w.put(int16(99))
+	if w.aok() {
+		w.put(int8(0), float32(0), float32(0), int16(-1), [6]int8{1, 2, 3, 4, 5, 6})
+	}
+}
+
+func (w *layout) tech() {
+	w.put([4]int16{-1, -1, -1, -1})
+	if w.aok() {
+		w.put([2]int16{-1, -1})
+	}
+	for i := 0; i < 3; i++ {
+		w.put(int16(-1), int16(0), int8(0))
+	}
+	w.put(int16(0))
+	if w.aok() {
+		w.put(int16(-1), int16(0))
+	}
+	w.put(int16(0), uint16(7000), uint16(8000), int16(60), int16(-1), int16(0), int16(0), int8(0))
+	w.put(int32(0), int32(0), int32(0), uint16(6))
+	w.WriteString("Wheel\x00")
+	if w.swgb() {
+		w.put(uint16(3))
+		w.WriteString("Rad")
+	}
+}
+
+func TestLayouts(t *testing.T) {
+	for v := dat.VersionAoE; v <= dat.VersionHD; v++ {
+		t.Run(v.String(), func(t *testing.T) {
+			w := &layout{v: v}
+			data := w.file()
+			terrains, _, _, _ := w.sizes()
+
+			f, err := dat.DecodeVersion(bytes.NewReader(data), v)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, f.TerrainBlock.Terrains, terrains)
+			assert.Equal(t, "Grass", f.TerrainBlock.Terrains[0].String())
+			assert.Equal(t, "ARCHR_STAND", f.Graphic(0).String())
+			assert.Equal(t, "Gaia", f.Civs[0].String())
+			assert.Equal(t, []float32{200}, f.Civs[0].Resources)
+
+			house := f.Unit(0, 0)
+			if assert.NotNil(t, house) {
+				assert.Equal(t, "HOUSE", house.String())
+				assert.Equal(t, int16(7), house.StandingGraphic)
+				assert.Equal(t, int16(900), house.HitPoints)
+				assert.Equal(t, int16(25), house.Creatable.TrainTime)
+				assert.Equal(t, int16(2), house.Creatable.DisplayedPierceArmor)
+				assert.Equal(t, int16(99), house.Building.ConstructionSound)
+			}
+			if assert.Len(t, f.Techs, 1) {
+				assert.Equal(t, "Wheel", f.Techs[0].String())
+				assert.Equal(t, int16(60), f.Techs[0].ResearchTime)
+			}
+
+			if v >= dat.VersionAoK {
+				assert.Equal(t, int32(16), f.PlayerColors[0].PlayerColorBase)
+				assert.Equal(t, int16(8), house.StandingGraphic2)
+				assert.Equal(t, int16(3), house.Combatant.BaseArmor)
+				assert.Equal(t, [6]int8{1, 2, 3, 4, 5, 6}, house.Building.LootingTable)
+				assert.Equal(t, int32(7), f.TechTree.TotalUnitTechGroups)
+			} else {
+				assert.Equal(t, "Blue", string(bytes.TrimRight(f.PlayerColors[0].Name[:], "\x00")))
+				assert.Equal(t, uint8(3), house.Combatant.OldBaseArmor)
+				assert.Nil(t, f.UnitHeaders)
+			}
+			if w.swgb() {
+				assert.Equal(t, uint16(1), f.SWGBCivCount)
+				assert.Equal(t, "Hut\x00", house.Name2)
+				assert.Equal(t, int16(5), house.UnitLine)
+				assert.Equal(t, "Rad", f.Techs[0].Name2)
+				if assert.Len(t, f.UnitLines, 1) {
+					assert.Equal(t, "Line", f.UnitLines[0].Name)
+				}
+			}
+
+			var buf bytes.Buffer
+			if assert.NoError(t, dat.EncodeRaw(&buf, f)) {
+				assert.True(t, bytes.Equal(data, buf.Bytes()), "the encoded data differs")
+			}
+		})
+	}
+}
+
+func TestDetectVersion(t *testing.T) {
+	for v := dat.VersionAoE; v <= dat.VersionHD; v++ {
+		t.Run(v.String(), func(t *testing.T) {
+			data := (&layout{v: v}).file()
+			f, err := dat.Decode(bytes.NewReader(data))
+			switch v {
+			case dat.VersionAoK, dat.VersionAoC, dat.VersionHD:
+				if assert.NoError(t, err) {
+					assert.Equal(t, v, f.GameVersion)
+				}
+			default:
+				// AoE and RoR, SWGB and CC have the same layout
+				assert.ErrorIs(t, err, dat.ErrAmbiguousVersion)
+			}
+
+			_, err = dat.Decode(bytes.NewReader(append(data, 0)))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package dat
+
+type (
+	// MapHeader describes a random map, the counts are repeated in Map.
+	MapHeader struct {
+		MapID             int32
+		BorderSouthWest   int32
+		BorderNorthWest   int32
+		BorderNorthEast   int32
+		BorderSouthEast   int32
+		BorderUsage       int32
+		WaterShape        int32
+		BaseTerrain       int32
+		LandCoverage      int32
+		UnusedID          int32
+		BaseZoneCount     uint32
+		BaseZonePtr       int32
+		MapTerrainCount   uint32
+		MapTerrainPtr     int32
+		MapUnitCount      uint32
+		MapUnitPtr        int32
+		MapElevationCount uint32
+		MapElevationPtr   int32
+	}
+
+	BaseZone struct {
+		PlayerID              int32
+		BaseTerrain           int32
+		SpacingBetweenPlayers int32
+		Unknown4              int32
+		Unknown5              [4]uint8
+		Unknown6              int32
+		Unknown7              int32
+		Unknown8              [4]uint8
+		StartAreaRadius       int32
+		Unknown10             int32
+		Unknown11             int32
+	}
+
+	MapTerrain struct {
+		Proportion       int32
+		Terrain          int32
+		NumberOfClumps   int32
+		EdgeSpacing      int32
+		PlacementTerrain int32
+		Clumpiness       int32
+	}
+
+	MapUnit struct {
+		Unit                  int32
+		HostTerrain           int32
+		GroupPlacing          int8
+		ScaleFlag             int8
+		Padding1              int16
+		ObjectsPerGroup       int32
+		Fluctuation           int32
+		GroupsPerPlayer       int32
+		GroupArea             int32
+		PlayerID              int32
+		SetPlaceForAllPlayers int32
+		MinDistanceToPlayers  int32
+		MaxDistanceToPlayers  int32
+	}
+
+	MapElevation struct {
+		Proportion    int32
+		Terrain       int32
+		ClumpCount    int32
+		BaseTerrain   int32
+		BaseElevation int32
+		TileSpacing   int32
+	}
+
+	Map struct {
+		MapHeader
+
+		BaseZones     []BaseZone     `bin:"len=BaseZoneCount"`
+		MapTerrains   []MapTerrain   `bin:"len=MapTerrainCount"`
+		MapUnits      []MapUnit      `bin:"len=MapUnitCount"`
+		MapElevations []MapElevation `bin:"len=MapElevationCount"`
+	}
+
+	// RandomMaps are the built-in random maps. They are unused since AoK,
+	// which uses RMS scripts instead.
+	RandomMaps struct {
+		RandomMapCount uint32
+		RandomMapsPtr  int32
+		Headers        []MapHeader `bin:"len=RandomMapCount"`
+		Maps           []Map       `bin:"len=RandomMapCount"`
+	}
+)
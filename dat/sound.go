@@ -0,0 +1,25 @@
+package dat
+
+import (
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+)
+
+type (
+	SoundItem struct {
+		FileName     []byte     `bin:"len=$SoundFileNameSize"`
+		ResourceID   drs.FileID // the WAV file
+		Probability  int16
+		Civilization int16 `bin:"ver=AoK-"`
+		IconSet      int16 `bin:"ver=AoK-"`
+	}
+
+	// Sound is a set of WAV files, one of them is picked randomly.
+	Sound struct {
+		ID        int16
+		PlayDelay int16
+		ItemCount uint16
+		CacheTime int32
+
+		Items []SoundItem `bin:"len=ItemCount"`
+	}
+)
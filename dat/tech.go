@@ -0,0 +1,43 @@
+package dat
+
+type (
+	ResearchResourceCost struct {
+		Type   int16
+		Amount int16
+		Flag   int8
+	}
+
+	// Tech is a technology that can be researched.
+	Tech struct {
+		RequiredTechs          [4]int16
+		RequiredTechs2         [2]int16 `bin:"ver=AoK-"` // the fifth and sixth
+		ResourceCosts          [3]ResearchResourceCost
+		RequiredTechCount      int16
+		Civ                    int16 `bin:"ver=AoK-"`
+		FullTechMode           int16 `bin:"ver=AoK-"`
+		ResearchLocation       int16 // unit ID
+		LanguageDLLName        uint16
+		LanguageDLLDescription uint16
+		ResearchTime           int16
+		EffectID               int16
+		Type                   int16
+		IconID                 int16
+		ButtonID               int8
+		LanguageDLLHelp        int32
+		LanguageDLLTechTree    int32
+		HotKey                 int32
+		NameLength             uint16
+		Name                   string `bin:"len=NameLength"`
+		Name2Length            uint16 `bin:"ver=SWGB-CC"`
+		Name2                  string `bin:"len=Name2Length,ver=SWGB-CC"`
+	}
+)
+
+func (t *Tech) String() string {
+	return cstring([]byte(t.Name))
+}
+
+// NameID returns the string ID of the name.
+func (t *Tech) NameID() uint32 {
+	return uint32(t.LanguageDLLName)
+}
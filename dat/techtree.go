@@ -0,0 +1,89 @@
+package dat
+
+const maxTechTreeItems = 40 // size of the building, unit and tech lists
+
+type (
+	TechTreeCommon struct {
+		SlotsUsed    int32
+		UnitResearch [10]int32
+		Mode         [10]int32
+	}
+
+	// TechTreeAge lists what becomes available in an age.
+	TechTreeAge struct {
+		ID                 int32
+		Status             int8
+		BuildingCount      uint8
+		Buildings          [maxTechTreeItems]int32
+		UnitCount          uint8
+		Units              [maxTechTreeItems]int32
+		TechCount          uint8
+		Techs              [maxTechTreeItems]int32
+		Common             TechTreeCommon
+		NumBuildingLevels  int8
+		BuildingsPerZone   [10]int8
+		GroupLengthPerZone [10]int8
+		MaxAgeLength       int8
+		LineMode           int32
+	}
+
+	BuildingConnection struct {
+		ID               int32
+		Status           int8
+		BuildingCount    uint8
+		Buildings        [maxTechTreeItems]int32
+		UnitCount        uint8
+		Units            [maxTechTreeItems]int32
+		TechCount        uint8
+		Techs            [maxTechTreeItems]int32
+		Common           TechTreeCommon
+		LocationInAge    int8
+		UnitsTechsTotal  [5]int8
+		UnitsTechsFirst  [5]int8
+		LineMode         int32
+		EnablingResearch int32
+	}
+
+	UnitConnection struct {
+		ID               int32
+		Status           int8
+		UpperBuilding    int32
+		Common           TechTreeCommon
+		VerticalLine     int32
+		UnitCount        uint8
+		Units            [maxTechTreeItems]int32
+		LocationInAge    int32
+		RequiredResearch int32
+		LineMode         int32
+		EnablingResearch int32
+	}
+
+	ResearchConnection struct {
+		ID            int32
+		Status        int8
+		UpperBuilding int32
+		BuildingCount uint8
+		Buildings     [maxTechTreeItems]int32
+		UnitCount     uint8
+		Units         [maxTechTreeItems]int32
+		TechCount     uint8
+		Techs         [maxTechTreeItems]int32
+		Common        TechTreeCommon
+		VerticalLine  int32
+		LocationInAge int32
+		LineMode      int32
+	}
+
+	// TechTree is the layout of the technology tree screen.
+	TechTree struct {
+		AgeCount            uint8
+		BuildingCount       uint8
+		UnitCount           uint8
+		ResearchCount       uint8
+		TotalUnitTechGroups int32
+		Ages                []TechTreeAge        `bin:"len=AgeCount"`
+		Buildings           []BuildingConnection `bin:"len=BuildingCount"`
+		Units               []UnitConnection     `bin:"len=UnitCount"`
+		Researches          []ResearchConnection `bin:"len=ResearchCount"`
+	}
+)
@@ -0,0 +1,171 @@
+package dat
+
+import (
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+)
+
+const (
+	NumTileSizes      = 19 // one per slope
+	NumTerrainBorders = 16
+	NumTerrainUnits   = 30
+)
+
+type (
+	TerrainPassGraphic struct {
+		ExitTileSpriteID  int32
+		EnterTileSpriteID int32
+		WalkTileSpriteID  int32
+		WalkSpriteRate    float32 `bin:"ver=-AoC|HD-"`
+		ReplicationAmount int32   `bin:"ver=SWGB-CC"`
+	}
+
+	// TerrainRestriction describes on which terrains a unit can move
+	// and build. Each terrain has a multiplier, 0 means impassable.
+	TerrainRestriction struct {
+		PassableBuildableDmgMultiplier []float32            `bin:"len=$TerrainsUsed"`
+		PassGraphics                   []TerrainPassGraphic `bin:"len=$TerrainsUsed,ver=AoK-"`
+	}
+
+	// PlayerColor describes the colors of a player. AoE and RoR store a
+	// named palette index instead.
+	PlayerColor struct {
+		Name            [30]byte `bin:"ver=-RoR"`
+		OldID           int16    `bin:"ver=-RoR"`
+		ResourceID      int16    `bin:"ver=-RoR"`
+		OldMinimapColor uint8    `bin:"ver=-RoR"`
+		Type            uint8    `bin:"ver=-RoR"`
+
+		ID                 int32    `bin:"ver=AoK-"`
+		PlayerColorBase    int32    `bin:"ver=AoK-"` // first palette index of the player colors
+		UnitOutlineColor   int32    `bin:"ver=AoK-"`
+		UnitSelectionColor [2]int32 `bin:"ver=AoK-"`
+		MinimapColor       [3]int32 `bin:"ver=AoK-"`
+		StatisticsText     int32    `bin:"ver=AoK-"`
+	}
+
+	TileSize struct {
+		Width  int16
+		Height int16
+		DeltaY int16
+	}
+
+	FrameData struct {
+		FrameCount int16
+		AngleCount int16
+		ShapeID    int16
+	}
+
+	// TerrainAnimation is shared by terrains and terrain borders.
+	TerrainAnimation struct {
+		IsAnimated        int8
+		AnimationFrames   int16
+		PauseFrames       int16
+		Interval          float32
+		PauseBetweenLoops float32
+		Frame             int16
+		DrawFrame         int16
+		AnimateLast       float32
+		FrameChanged      int8
+		Drawn             int8
+	}
+
+	Terrain struct {
+		Enabled  int8
+		Random   int8
+		Name     []byte `bin:"len=$TerrainNameSize"`
+		Name2    []byte `bin:"len=$TerrainNameSize"` // SLP name
+		SLP      drs.FileID
+		ShapePtr int32
+		SoundID  int32
+
+		BlendPriority int32 `bin:"ver=AoK-"`
+		BlendType     int32 `bin:"ver=AoK-"`
+
+		Colors            [3]uint8
+		CliffColors       [2]uint8 `bin:"ver=AoK-"`
+		PassableTerrain   int8
+		ImpassableTerrain int8
+
+		TerrainAnimation
+
+		ElevationGraphics [NumTileSizes]FrameData
+		TerrainToDraw     int16
+		TerrainDimensions [2]int16 // rows and columns
+		Borders           []int16  `bin:"len=$TerrainCount"`
+
+		TerrainUnitID            [NumTerrainUnits]int16
+		TerrainUnitDensity       [NumTerrainUnits]int16
+		TerrainUnitCentering     [NumTerrainUnits]int8
+		NumberOfTerrainUnitsUsed int16
+		Phantom                  int16
+	}
+
+	TerrainBorder struct {
+		Enabled  int8
+		Random   int8
+		Name     []byte `bin:"len=$TerrainNameSize"`
+		Name2    []byte `bin:"len=$TerrainNameSize"`
+		SLP      drs.FileID
+		ShapePtr int32
+		SoundID  int32
+		Colors   [3]uint8
+
+		TerrainAnimation
+
+		Borders         [NumTileSizes][12]FrameData
+		DrawTile        int16
+		UnderlayTerrain int16
+		BorderStyle     int16
+	}
+
+	// TerrainBlock holds the terrains and the map state of the engine,
+	// most of which is runtime data saved with the file.
+	TerrainBlock struct {
+		VirtualFunctionPtr int32
+		MapPointer         int32
+		MapWidth           int32
+		MapHeight          int32
+		WorldWidth         int32
+		WorldHeight        int32
+		TileSizes          [NumTileSizes]TileSize
+		PaddingTS          int16 `bin:"ver=AoK-"`
+
+		Terrains       []Terrain `bin:"len=$TerrainCount"`
+		TerrainBorders [NumTerrainBorders]TerrainBorder
+
+		MapRowOffset     int32
+		MapMinX          float32
+		MapMinY          float32
+		MapMaxX          float32
+		MapMaxY          float32
+		MapMaxXplus1     float32
+		MapMaxYplus1     float32
+		TerrainsUsed2    int16
+		BordersUsed      int16
+		MaxTerrain       int16
+		TileWidth        int16
+		TileHeight       int16
+		TileHalfHeight   int16
+		TileHalfWidth    int16
+		ElevHeight       int16
+		CurRow           int16
+		CurCol           int16
+		BlockBegRow      int16
+		BlockEndRow      int16
+		BlockBegCol      int16
+		BlockEndCol      int16
+		SearchMapPtr     int32
+		SearchMapRowsPtr int32
+		AnyFrameChange   int8
+		MapVisibleFlag   int8
+		FogFlag          int8
+
+		SomeBytes [21]uint8  `bin:"ver=AoK-"`
+		SomeInt32 [157]int32 `bin:"ver=AoK-"`
+	}
+)
+
+// String returns the name of the terrain.
+func (t *Terrain) String() string {
+	return cstring(t.Name)
+}
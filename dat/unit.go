@@ -0,0 +1,309 @@
+package dat
+
+import (
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+)
+
+// Unit types. The type determines which sections a unit has, e.g. a
+// building (80) has all sections except the projectile one.
+const (
+	UnitTypeEyeCandy   = 10
+	UnitTypeFlag       = 20 // has a speed
+	UnitTypeDeadFish   = 30 // can move
+	UnitTypeBird       = 40 // can do tasks
+	UnitTypeCombatant  = 50 // can attack
+	UnitTypeProjectile = 60
+	UnitTypeCreatable  = 70 // can be trained
+	UnitTypeBuilding   = 80
+	UnitTypeTree       = 90
+)
+
+type (
+	Task struct {
+		TaskType                 int16
+		ID                       int16
+		IsDefault                int8
+		ActionType               int16
+		ClassID                  int16
+		UnitID                   int16
+		TerrainID                int16
+		ResourceIn               int16
+		ResourceMultiplier       int16
+		ResourceOut              int16
+		UnusedResource           int16
+		WorkValue1               float32
+		WorkValue2               float32
+		WorkRange                float32
+		AutoSearchTargets        int8
+		SearchWaitTime           float32
+		EnableTargeting          int8
+		CombatLevelFlag          int8
+		GatherType               int16
+		WorkFlag2                int16
+		TargetDiplomacy          int8
+		CarryCheck               int8
+		PickForConstruction      int8
+		MovingGraphicID          int16
+		ProceedingGraphicID      int16
+		WorkingGraphicID         int16
+		CarryingGraphicID        int16
+		ResourceGatheringSoundID int16
+		ResourceDepositSoundID   int16
+	}
+
+	// UnitHeader holds the tasks of a unit, shared by all civilizations.
+	UnitHeader struct {
+		Exists    uint8
+		TaskCount uint16 `bin:"if=Exists!=0"`
+		Tasks     []Task `bin:"len=TaskCount,if=Exists!=0"`
+	}
+
+	// UnitLine groups the units of an upgrade line (SWGB and CC).
+	UnitLine struct {
+		ID         int16
+		NameLength uint16
+		Name       string `bin:"len=NameLength"`
+		UnitCount  uint16
+		Units      []int16 `bin:"len=UnitCount"`
+	}
+
+	ResourceStorage struct {
+		Type   int16
+		Amount float32
+		Flag   int8
+	}
+
+	DamageGraphic struct {
+		GraphicID     int16
+		DamagePercent int8
+		OldApplyMode  int8
+		ApplyMode     int8
+	}
+
+	DeadFish struct {
+		WalkingGraphic              int16
+		RunningGraphic              int16
+		RotationSpeed               float32
+		OldSizeClass                int8
+		TrackingUnit                int16
+		TrackingUnitMode            uint8
+		TrackingUnitDensity         float32
+		OldMoveAlgorithm            int8
+		TurnRadius                  float32 `bin:"ver=AoK-"`
+		TurnRadiusSpeed             float32 `bin:"ver=AoK-"`
+		MaxYawPerSecondMoving       float32 `bin:"ver=AoK-"`
+		StationaryYawRevolutionTime float32 `bin:"ver=AoK-"`
+		MaxYawPerSecondStationary   float32 `bin:"ver=AoK-"`
+	}
+
+	Bird struct {
+		DefaultTaskID int16
+		SearchRadius  float32
+		WorkRate      float32
+		DropSites     [2]int16
+		TaskSwapGroup int8
+		AttackSound   int16
+		MoveSound     int16
+		RunPattern    int8
+
+		// AoE and RoR store the tasks in the unit, see UnitHeader.
+		TaskCount uint16 `bin:"ver=-RoR"`
+		Tasks     []Task `bin:"len=TaskCount,ver=-RoR"`
+	}
+
+	AttackOrArmor struct {
+		Class  int16
+		Amount int16
+	}
+
+	Combatant struct {
+		OldBaseArmor        uint8 `bin:"ver=-RoR"` // BaseArmor of AoE and RoR
+		BaseArmor           int16 `bin:"ver=AoK-"`
+		AttackCount         uint16
+		Attacks             []AttackOrArmor `bin:"len=AttackCount"`
+		ArmorCount          uint16
+		Armors              []AttackOrArmor `bin:"len=ArmorCount"`
+		DefenseTerrainBonus int16
+		MaxRange            float32
+		BlastWidth          float32
+		ReloadTime          float32
+		ProjectileUnitID    int16
+		AccuracyPercent     int16
+		BreakOffCombat      int8
+		FrameDelay          int16
+		GraphicDisplacement [3]float32
+		BlastAttackLevel    int8
+		MinRange            float32
+		AccuracyDispersion  float32 `bin:"ver=AoK-"`
+		AttackGraphic       int16
+		DisplayedMeleeArmor int16
+		DisplayedAttack     int16
+		DisplayedRange      float32
+		DisplayedReloadTime float32
+	}
+
+	Projectile struct {
+		ProjectileType     int8
+		SmartMode          int8
+		HitMode            int8
+		VanishMode         int8
+		AreaEffectSpecials int8
+		ProjectileArc      float32
+	}
+
+	ResourceCost struct {
+		Type   int16
+		Amount int16
+		Flag   int16
+	}
+
+	Creatable struct {
+		ResourceCosts           [3]ResourceCost
+		TrainTime               int16
+		TrainLocationID         int16
+		ButtonID                int8
+		RearAttackModifier      float32    `bin:"ver=AoK-"`
+		FlankAttackModifier     float32    `bin:"ver=AoK-"`
+		CreatableType           int8       `bin:"ver=AoK-"`
+		HeroMode                int8       `bin:"ver=AoK-"`
+		GarrisonGraphic         int32      `bin:"ver=AoK-"`
+		TotalProjectiles        float32    `bin:"ver=AoK-"`
+		MaxTotalProjectiles     int8       `bin:"ver=AoK-"`
+		ProjectileSpawningArea  [3]float32 `bin:"ver=AoK-"`
+		SecondaryProjectileUnit int32      `bin:"ver=AoK-"`
+		SpecialGraphic          int32      `bin:"ver=AoK-"`
+		SpecialAbility          int8       `bin:"ver=AoK-"`
+		DisplayedPierceArmor    int16
+	}
+
+	BuildingAnnex struct {
+		UnitID       int16
+		Misplacement [2]float32
+	}
+
+	Building struct {
+		ConstructionGraphicID int16
+		SnowGraphicID         int16 `bin:"ver=AoC-"`
+		AdjacentMode          int8
+		GraphicsAngle         int16
+		DisappearsWhenBuilt   int8
+		StackUnitID           int16
+		FoundationTerrainID   int16
+		OldOverlayID          int16
+		TechID                int16
+		CanBurn               int8             `bin:"ver=AoK-"`
+		Annexes               [4]BuildingAnnex `bin:"ver=AoK-"`
+		HeadUnit              int16            `bin:"ver=AoK-"`
+		TransformUnit         int16            `bin:"ver=AoK-"`
+		TransformSound        int16            `bin:"ver=AoK-"`
+		ConstructionSound     int16
+		GarrisonType          int8    `bin:"ver=AoK-"`
+		GarrisonHealRate      float32 `bin:"ver=AoK-"`
+		GarrisonRepairRate    float32 `bin:"ver=AoK-"`
+		PileUnit              int16   `bin:"ver=AoK-"`
+		LootingTable          [6]int8 `bin:"ver=AoK-"`
+	}
+
+	// Unit is a unit of a civilization. The sections after the common
+	// fields depend on the type and are nil if they do not exist.
+	Unit struct {
+		Type                      int8
+		NameLength                uint16
+		ID                        int16
+		LanguageDLLName           uint16
+		LanguageDLLCreation       uint16
+		Class                     int16
+		StandingGraphic           int16
+		StandingGraphic2          int16 `bin:"ver=AoK-"`
+		DyingGraphic              int16
+		UndeadGraphic             int16
+		UndeadMode                int8
+		HitPoints                 int16
+		LineOfSight               float32
+		GarrisonCapacity          int8
+		CollisionSize             [3]float32
+		TrainSound                int16
+		DamageSound               int16 `bin:"ver=AoK-"`
+		DeadUnitID                int16
+		SortNumber                int8
+		CanBeBuiltOn              int8
+		IconID                    int16
+		HideInEditor              int8
+		OldPortraitPict           int16
+		Enabled                   int8
+		Disabled                  int8 `bin:"ver=AoK-"`
+		PlacementSideTerrain      [2]int16
+		PlacementTerrain          [2]int16
+		ClearanceSize             [2]float32
+		HillMode                  int8
+		FogVisibility             int8
+		TerrainRestriction        int16
+		FlyMode                   int8
+		ResourceCapacity          int16
+		ResourceDecay             float32
+		BlastDefenseLevel         int8
+		CombatLevel               int8
+		InteractionMode           int8
+		MinimapMode               int8
+		InterfaceKind             int8
+		MultipleAttributeMode     float32
+		MinimapColor              uint8
+		LanguageDLLHelp           int32      `bin:"ver=AoK-"`
+		LanguageDLLHotKeyText     int32      `bin:"ver=AoK-"`
+		HotKey                    int32      `bin:"ver=AoK-"`
+		Recyclable                int8       `bin:"ver=AoK-"`
+		EnableAutoGather          int8       `bin:"ver=AoK-"`
+		CreateDoppelgangerOnDeath int8       `bin:"ver=AoK-"`
+		ResourceGatherGroup       int8       `bin:"ver=AoK-"`
+		OcclusionMode             uint8      `bin:"ver=AoK-"`
+		ObstructionType           int8       `bin:"ver=AoK-"`
+		ObstructionClass          int8       `bin:"ver=AoK-"`
+		Trait                     uint8      `bin:"ver=AoK-"`
+		Civilization              int8       `bin:"ver=AoK-"`
+		Nothing                   int16      `bin:"ver=AoK-"`
+		SelectionEffect           int8       `bin:"ver=AoK-"`
+		EditorSelectionColor      uint8      `bin:"ver=AoK-"`
+		OutlineSize               [3]float32 `bin:"ver=AoK-"`
+		ResourceStorages          [3]ResourceStorage
+		DamageGraphicCount        uint8
+		DamageGraphics            []DamageGraphic `bin:"len=DamageGraphicCount"`
+		SelectionSound            int16
+		DyingSound                int16
+		OldAttackReaction         int8
+		ConvertTerrain            int8
+		Name                      string `bin:"len=NameLength"`
+		Name2Length               uint16 `bin:"ver=SWGB-CC"`
+		Name2                     string `bin:"len=Name2Length,ver=SWGB-CC"`
+		UnitLine                  int16  `bin:"ver=SWGB-CC"`
+		MinTechLevel              int8   `bin:"ver=SWGB-CC"`
+		CopyID                    int16
+		BaseID                    int16 `bin:"ver=AoK-"`
+
+		Speed float32 `bin:"if=Type>=20&Type!=90"`
+
+		DeadFish   *DeadFish   `bin:"if=Type>=30&Type!=90"`
+		Bird       *Bird       `bin:"if=Type>=40&Type!=90"`
+		Combatant  *Combatant  `bin:"if=Type>=50&Type!=90"`
+		Projectile *Projectile `bin:"if=Type==60"`
+		Creatable  *Creatable  `bin:"if=Type>=70&Type!=90"`
+		Building   *Building   `bin:"if=Type==80"`
+	}
+)
+
+func (u *Unit) String() string {
+	return cstring([]byte(u.Name))
+}
+
+// NameID returns the string ID of the name.
+func (u *Unit) NameID() uint32 {
+	return uint32(u.LanguageDLLName)
+}
+
+// LocalizedName returns the name of the unit from the language strings. It
+// falls back to the internal name if the string does not exist.
+func (u *Unit) LocalizedName(r lang.Resolver) string {
+	if s, found := r.Get(u.NameID()); found {
+		return s
+	}
+	return u.String()
+}
@@ -0,0 +1,169 @@
+package dat
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+)
+
+// Version is the game edition of a DAT file. The editions are ordered by
+// release, the tag `ver=AoK-` selects AoK and every later edition. The
+// Definitive Edition ("VER 7.8" and later) is not supported, most of its
+// structs differ from HD.
+type Version int
+
+const (
+	VersionUnknown Version = iota
+	VersionAoE             // Age of Empires
+	VersionRoR             // The Rise of Rome
+	VersionAoK             // The Age of Kings
+	VersionAoC             // The Conquerors
+	VersionSWGB            // Star Wars: Galactic Battlegrounds
+	VersionCC              // Clone Campaigns
+	VersionHD              // HD Edition
+)
+
+type versionInfo struct {
+	name string
+	tag  string // version string of the file
+
+	// vars are the values which are not stored in the file, see the
+	// $Var tags.
+	vars map[string]int
+}
+
+// aocNames are the sizes of the fixed length names used by most editions.
+var aocNames = map[string]int{
+	"NameSize":          21,
+	"FileNameSize":      13,
+	"SoundFileNameSize": 13,
+	"TerrainNameSize":   13,
+	"EffectNameSize":    31,
+	"CivNameSize":       20,
+}
+
+var swgbNames = map[string]int{
+	"NameSize":          25,
+	"FileNameSize":      25,
+	"SoundFileNameSize": 27,
+	"TerrainNameSize":   17,
+	"EffectNameSize":    31,
+	"CivNameSize":       20,
+}
+
+var versionInfos = [...]versionInfo{
+	VersionAoE:  {"AoE", "VER 3.7", withVars(aocNames, "TerrainCount", 32)},
+	VersionRoR:  {"RoR", "VER 3.7", withVars(aocNames, "TerrainCount", 32)},
+	VersionAoK:  {"AoK", "VER 5.7", withVars(aocNames, "TerrainCount", 32)},
+	VersionAoC:  {"AoC", "VER 5.7", withVars(aocNames, "TerrainCount", 42)},
+	VersionSWGB: {"SWGB", "VER 5.9", withVars(swgbNames, "TerrainCount", 55)},
+	VersionCC:   {"CC", "VER 5.9", withVars(swgbNames, "TerrainCount", 55)},
+	VersionHD:   {"HD", "VER 5.7", withVars(aocNames, "TerrainCount", 100)},
+}
+
+func withVars(base map[string]int, name string, value int) map[string]int {
+	res := map[string]int{name: value}
+	for k, v := range base {
+		res[k] = v
+	}
+	return res
+}
+
+// versionsOf returns the editions using the version string tag.
+func versionsOf(tag string) []Version {
+	var res []Version
+	for v := VersionAoE; v <= VersionHD; v++ {
+		if versionInfos[v].tag == tag {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// ParseVersion returns the version with the given name, e.g. "AoC".
+func ParseVersion(name string) (Version, error) {
+	for v := VersionAoE; v <= VersionHD; v++ {
+		if strings.EqualFold(versionInfos[v].name, name) {
+			return v, nil
+		}
+	}
+	return VersionUnknown, fmt.Errorf("%w: %q", ErrUnsupportedVersion, name)
+}
+
+// IsValid returns true for the known versions.
+func (v Version) IsValid() bool {
+	return v >= VersionAoE && v <= VersionHD
+}
+
+func (v Version) String() string {
+	if !v.IsValid() {
+		return fmt.Sprintf("Version(%d)", int(v))
+	}
+	return versionInfos[v].name
+}
+
+// Tag returns the version string stored in the file, e.g. "VER 5.7".
+func (v Version) Tag() string {
+	if !v.IsValid() {
+		return ""
+	}
+	return versionInfos[v].tag
+}
+
+// config returns the codec configuration for v.
+func (v Version) config() codec.Config {
+	return codec.Config{
+		Vars: versionInfos[v].vars,
+		Version: func(expr string) bool {
+			ranges, err := parseVersions(expr)
+			if err != nil {
+				panic(fmt.Errorf("dat: invalid ver tag: %w", err))
+			}
+			return inVersions(ranges, v)
+		},
+	}
+}
+
+// versionRange is an inclusive range of versions.
+type versionRange struct {
+	from, to Version
+}
+
+// parseVersions parses a version expression: ranges separated by '|',
+// e.g. "AoK-", "-RoR", "SWGB-CC" or "-AoC|HD-".
+func parseVersions(expr string) ([]versionRange, error) {
+	var res []versionRange
+	for _, part := range strings.Split(expr, "|") {
+		r := versionRange{VersionAoE, VersionHD}
+		from, to, isRange := strings.Cut(part, "-")
+		var err error
+		if from != "" {
+			if r.from, err = ParseVersion(from); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case !isRange:
+			r.to = r.from
+		case to != "":
+			if r.to, err = ParseVersion(to); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func inVersions(ranges []versionRange, v Version) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if v >= r.from && v <= r.to {
+			return true
+		}
+	}
+	return false
+}
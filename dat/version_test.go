@@ -0,0 +1,98 @@
+package dat_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := dat.ParseVersion("aoc")
+	if assert.NoError(t, err) {
+		assert.Equal(t, dat.VersionAoC, v)
+		assert.Equal(t, "AoC", v.String())
+		assert.Equal(t, "VER 5.7", v.Tag())
+	}
+	for _, name := range []string{"AoM", "DE"} {
+		_, err = dat.ParseVersion(name)
+		assert.ErrorIs(t, err, dat.ErrUnsupportedVersion)
+	}
+	assert.False(t, dat.VersionUnknown.IsValid())
+}
+
+// convert changes the version of f and resizes the terrains to match.
+func convert(f *dat.File, version dat.Version, terrains int) {
+	f.GameVersion = version
+	copy(f.Version[:], version.Tag())
+	ts := make([]dat.Terrain, terrains)
+	copy(ts, f.TerrainBlock.Terrains)
+	for i := range ts {
+		b := make([]int16, terrains)
+		copy(b, ts[i].Borders)
+		ts[i].Borders = b
+	}
+	f.TerrainBlock.Terrains = ts
+}
+
+func TestVersions(t *testing.T) {
+	tests := []struct {
+		version  dat.Version
+		terrains int
+	}{
+		{dat.VersionAoE, 32},
+		{dat.VersionRoR, 32},
+		{dat.VersionAoK, 32},
+		{dat.VersionSWGB, 55},
+		{dat.VersionHD, 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.version.String(), func(t *testing.T) {
+			f, err := dat.Open("./testdata/empires2_x1_p1.dat")
+			if !assert.NoError(t, err) {
+				return
+			}
+			convert(f, tc.version, tc.terrains)
+			f.Unit(0, 4).Bird.Tasks = []dat.Task{{TaskType: 1, ActionType: 7}}
+
+			var buf bytes.Buffer
+			if !assert.NoError(t, dat.Encode(&buf, f)) {
+				return
+			}
+			f2, err := dat.DecodeVersion(&buf, tc.version)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.version, f2.GameVersion)
+			assert.Len(t, f2.TerrainBlock.Terrains, tc.terrains)
+			assert.Equal(t, "ARCHR_STAND", f2.Graphic(0).String())
+			assert.Equal(t, "Grass 1", f2.TerrainBlock.Terrains[0].String())
+
+			archer := f2.Unit(0, 4)
+			if tc.version <= dat.VersionRoR {
+				assert.Nil(t, f2.UnitHeaders)
+				assert.Nil(t, f2.TechTree.Ages)
+				assert.Len(t, archer.Bird.Tasks, 1)
+				assert.Zero(t, archer.CopyID)
+			} else {
+				assert.Len(t, f2.UnitHeaders, 5)
+				assert.Len(t, f2.TechTree.Ages, 1)
+				assert.Nil(t, archer.Bird.Tasks)
+			}
+			assert.Equal(t, int16(35), archer.Creatable.TrainTime)
+		})
+	}
+}
+
+func TestDecodeVersionMismatch(t *testing.T) {
+	fh, err := os.Open("./testdata/empires2_x1_p1.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	_, err = dat.DecodeVersion(fh, dat.VersionSWGB)
+	assert.ErrorIs(t, err, dat.ErrVersionMismatch)
+}
@@ -0,0 +1,35 @@
+// Read filtermaps.dat and lightmaps.dat and render sloped terrain tiles.
+//
+// Sloped (elevated) terrain tiles are not stored in the terrain graphics.
+// They are generated from the flat tile by texture mapping: every pixel of
+// a sloped tile is a weighted sum of pixels of the flat tile. The resulting
+// RGB value is converted back to a palette index with an inverse color map
+// (see package icm), whose row is selected by the lightmap.
+//
+// # Flat tiles
+//
+// A flat tile is a 97x49 diamond of 2353 pixels. The source indices of the
+// filtermaps count these pixels row by row, from left to right, skipping the
+// transparent corners (see FlatPixels).
+//
+// # filtermaps.dat
+//
+//	uint32 number of filtermaps (one per slope, see NumSlopes)
+//	per filtermap:
+//	    uint32 number of lines
+//	    per line:
+//	        uint16 x offset of the first pixel
+//	        uint16 number of pixels
+//	        per pixel:
+//	            uint8 number of sources
+//	            per source:
+//	                uint16 index of the flat tile pixel
+//	                uint8  weight
+//
+// # lightmaps.dat
+//
+//	uint32 number of lightmaps (one per slope)
+//	per lightmap:
+//	    uint32 number of pixels
+//	    uint8  ICM row per pixel, in the pixel order of the filtermap
+package filtermap
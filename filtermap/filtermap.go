@@ -0,0 +1,195 @@
+package filtermap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+const (
+	NumSlopes = 19 // number of slope types
+
+	TileWidth  = 97
+	TileHeight = 49
+	TilePixels = 2353 // number of pixels of a flat tile
+
+	maxLength = 1 << 20 // sanity limit for counts read from the file
+)
+
+var (
+	ErrInvalidSource = errors.New("filtermap: invalid source pixel")
+	ErrInvalidLength = errors.New("filtermap: invalid length")
+)
+
+type (
+	Source struct {
+		Index  uint16 // index of the flat tile pixel
+		Weight uint8
+	}
+
+	Pixel struct {
+		Sources []Source
+	}
+
+	Line struct {
+		X      int // x offset of the first pixel
+		Pixels []Pixel
+	}
+
+	// Filtermap maps the pixels of a flat tile to a sloped tile.
+	Filtermap struct {
+		Lines []Line
+	}
+
+	// Lightmap contains the ICM row for every pixel of a sloped tile.
+	Lightmap []uint8
+)
+
+// Weight returns the sum of the weights of the sources. A pixel without
+// weight is not covered by the flat tile.
+func (px *Pixel) Weight() int {
+	w := 0
+	for _, s := range px.Sources {
+		w += int(s.Weight)
+	}
+	return w
+}
+
+// Bounds returns the size of the sloped tile.
+func (fm *Filtermap) Bounds() image.Rectangle {
+	w := 0
+	for _, ln := range fm.Lines {
+		w = max(w, ln.X+len(ln.Pixels))
+	}
+	return image.Rect(0, 0, w, len(fm.Lines))
+}
+
+// NumPixels returns the number of pixels of the sloped tile.
+func (fm *Filtermap) NumPixels() (n int) {
+	for _, ln := range fm.Lines {
+		n += len(ln.Pixels)
+	}
+	return
+}
+
+// FlatPixels returns the positions of the flat tile pixels relative to the
+// top left corner, in the order used by Source.Index.
+func FlatPixels() []image.Point {
+	res := make([]image.Point, 0, TilePixels)
+	center := TileWidth / 2
+	for y := 0; y < TileHeight; y++ {
+		half := 2 * min(y, TileHeight-1-y)
+		for x := center - half; x <= center+half; x++ {
+			res = append(res, image.Point{X: x, Y: y})
+		}
+	}
+	return res
+}
+
+func Open(filename string) ([]Filtermap, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return Parse(bufio.NewReader(fh))
+}
+
+// Parse parses filtermaps.dat.
+func Parse(rd io.Reader) ([]Filtermap, error) {
+	var count uint32
+	if err := binary.Read(rd, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read filtermap count: %w", err)
+	}
+	if count > maxLength {
+		return nil, fmt.Errorf("%w: %d filtermaps", ErrInvalidLength, count)
+	}
+	res := make([]Filtermap, count)
+	for i := range res {
+		if err := res[i].parse(rd); err != nil {
+			return nil, fmt.Errorf("filtermap %d: %w", i, err)
+		}
+	}
+	return res, nil
+}
+
+func (fm *Filtermap) parse(rd io.Reader) error {
+	var numLines uint32
+	if err := binary.Read(rd, binary.LittleEndian, &numLines); err != nil {
+		return fmt.Errorf("failed to read line count: %w", err)
+	}
+	if numLines > maxLength {
+		return fmt.Errorf("%w: %d lines", ErrInvalidLength, numLines)
+	}
+	fm.Lines = make([]Line, numLines)
+	for y := range fm.Lines {
+		var hdr struct {
+			X         uint16
+			NumPixels uint16
+		}
+		if err := binary.Read(rd, binary.LittleEndian, &hdr); err != nil {
+			return fmt.Errorf("line %d: %w", y, err)
+		}
+		ln := &fm.Lines[y]
+		ln.X = int(hdr.X)
+		ln.Pixels = make([]Pixel, hdr.NumPixels)
+		for x := range ln.Pixels {
+			var n [1]byte
+			if _, err := io.ReadFull(rd, n[:]); err != nil {
+				return fmt.Errorf("line %d, pixel %d: %w", y, x, err)
+			}
+			src := make([]Source, n[0])
+			for j := range src {
+				var buf [3]byte
+				if _, err := io.ReadFull(rd, buf[:]); err != nil {
+					return fmt.Errorf("line %d, pixel %d: %w", y, x, err)
+				}
+				src[j] = Source{Index: binary.LittleEndian.Uint16(buf[:]), Weight: buf[2]}
+				if src[j].Index >= TilePixels {
+					return fmt.Errorf("%w: line %d, pixel %d: %d", ErrInvalidSource, y, x, src[j].Index)
+				}
+			}
+			ln.Pixels[x].Sources = src
+		}
+	}
+	return nil
+}
+
+func OpenLightmaps(filename string) ([]Lightmap, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return ParseLightmaps(bufio.NewReader(fh))
+}
+
+// ParseLightmaps parses lightmaps.dat.
+func ParseLightmaps(rd io.Reader) ([]Lightmap, error) {
+	var count uint32
+	if err := binary.Read(rd, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read lightmap count: %w", err)
+	}
+	if count > maxLength {
+		return nil, fmt.Errorf("%w: %d lightmaps", ErrInvalidLength, count)
+	}
+	res := make([]Lightmap, count)
+	for i := range res {
+		var n uint32
+		if err := binary.Read(rd, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("lightmap %d: %w", i, err)
+		}
+		if n > maxLength {
+			return nil, fmt.Errorf("%w: lightmap %d: %d pixels", ErrInvalidLength, i, n)
+		}
+		res[i] = make(Lightmap, n)
+		if _, err := io.ReadFull(rd, res[i]); err != nil {
+			return nil, fmt.Errorf("lightmap %d: %w", i, err)
+		}
+	}
+	return res, nil
+}
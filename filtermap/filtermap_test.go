@@ -0,0 +1,156 @@
+package filtermap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/filtermap"
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type source struct {
+	index  uint16
+	weight uint8
+}
+
+type line struct {
+	x      uint16
+	pixels [][]source
+}
+
+func buildFiltermaps(maps ...[]line) []byte {
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	binary.Write(&buf, le, uint32(len(maps)))
+	for _, lines := range maps {
+		binary.Write(&buf, le, uint32(len(lines)))
+		for _, ln := range lines {
+			binary.Write(&buf, le, [2]uint16{ln.x, uint16(len(ln.pixels))})
+			for _, px := range ln.pixels {
+				buf.WriteByte(byte(len(px)))
+				for _, s := range px {
+					binary.Write(&buf, le, s.index)
+					buf.WriteByte(s.weight)
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func buildLightmaps(maps ...[]byte) []byte {
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	binary.Write(&buf, le, uint32(len(maps)))
+	for _, m := range maps {
+		binary.Write(&buf, le, uint32(len(m)))
+		buf.Write(m)
+	}
+	return buf.Bytes()
+}
+
+// testFiltermap copies the top corner of the flat tile and averages the two
+// pixels left of the center of the second line.
+var testFiltermap = []line{
+	{x: 48, pixels: [][]source{{{0, 1}}}},
+	{x: 47, pixels: [][]source{{{1, 1}, {2, 3}}, {}}},
+}
+
+func TestFlatPixels(t *testing.T) {
+	px := filtermap.FlatPixels()
+	assert.Len(t, px, filtermap.TilePixels)
+	assert.Equal(t, image.Pt(48, 0), px[0])
+	assert.Equal(t, image.Pt(46, 1), px[1])
+	assert.Equal(t, image.Pt(0, 24), px[len(px)/2-48])
+	assert.Equal(t, image.Pt(48, 48), px[len(px)-1])
+}
+
+func TestParse(t *testing.T) {
+	fms, err := filtermap.Parse(bytes.NewReader(buildFiltermaps(testFiltermap)))
+	if !assert.NoError(t, err) || !assert.Len(t, fms, 1) {
+		return
+	}
+	fm := &fms[0]
+	assert.Equal(t, image.Rect(0, 0, 49, 2), fm.Bounds())
+	assert.Equal(t, 3, fm.NumPixels())
+	assert.Equal(t, []filtermap.Source{{Index: 1, Weight: 1}, {Index: 2, Weight: 3}}, fm.Lines[1].Pixels[0].Sources)
+	assert.Equal(t, 4, fm.Lines[1].Pixels[0].Weight())
+
+	lms, err := filtermap.ParseLightmaps(bytes.NewReader(buildLightmaps([]byte{0, 1, 0})))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []filtermap.Lightmap{{0, 1, 0}}, lms)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := filtermap.Parse(bytes.NewReader(buildFiltermaps([]line{{pixels: [][]source{{{filtermap.TilePixels, 1}}}}})))
+	assert.ErrorIs(t, err, filtermap.ErrInvalidSource)
+
+	huge := []byte{0xff, 0xff, 0xff, 0xff}
+	_, err = filtermap.Parse(bytes.NewReader(huge))
+	assert.ErrorIs(t, err, filtermap.ErrInvalidLength)
+	_, err = filtermap.Parse(bytes.NewReader(append([]byte{1, 0, 0, 0}, huge...)))
+	assert.ErrorIs(t, err, filtermap.ErrInvalidLength)
+	_, err = filtermap.ParseLightmaps(bytes.NewReader(huge))
+	assert.ErrorIs(t, err, filtermap.ErrInvalidLength)
+	_, err = filtermap.ParseLightmaps(bytes.NewReader(append([]byte{1, 0, 0, 0}, huge...)))
+	assert.ErrorIs(t, err, filtermap.ErrInvalidLength)
+
+	_, err = filtermap.Parse(bytes.NewReader(buildFiltermaps(testFiltermap)[:10]))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	fms, err := filtermap.Parse(bytes.NewReader(buildFiltermaps(testFiltermap)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the map returns the upper 5 bits of red, plus 32 per row
+	var m icm.Map
+	for row := range m {
+		for r := range m[row] {
+			for g := range m[row][r] {
+				for b := range m[row][r][g] {
+					m[row][r][g][b] = byte(row*32 + r)
+				}
+			}
+		}
+	}
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{R: uint8(i), A: 255}
+	}
+	flat := image.NewRGBA(image.Rect(0, 0, filtermap.TileWidth, filtermap.TileHeight))
+	flat.Set(48, 0, color.RGBA{R: 80, A: 255})
+	flat.Set(46, 1, color.RGBA{R: 40, A: 255})
+	flat.Set(47, 1, color.RGBA{R: 240, A: 255})
+
+	r := &filtermap.Renderer{Filtermaps: fms, ICM: &m, Palette: pal}
+	img, err := r.Render(0, flat)
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 49, 2), img.Bounds())
+		assert.Equal(t, uint8(80>>3), img.ColorIndexAt(48, 0))
+		assert.Equal(t, uint8((40+3*240)/4>>3), img.ColorIndexAt(47, 1))
+		assert.Equal(t, uint8(0), img.ColorIndexAt(48, 1), "pixel without sources")
+	}
+
+	r.Lightmaps = []filtermap.Lightmap{{0, 1, 0}}
+	img, err = r.Render(0, flat)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint8(32+(40+3*240)/4>>3), img.ColorIndexAt(47, 1))
+	}
+
+	_, err = r.Render(1, flat)
+	assert.Error(t, err)
+	r.Lightmaps = []filtermap.Lightmap{{0}}
+	_, err = r.Render(0, flat)
+	assert.Error(t, err)
+	r.ICM = nil
+	_, err = r.Render(0, flat)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package filtermap
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+)
+
+// Renderer renders sloped terrain tiles.
+type Renderer struct {
+	Filtermaps []Filtermap
+	Lightmaps  []Lightmap // optional; without lightmaps row 0 of the ICM is used
+	ICM        *icm.Map
+	Palette    color.Palette // the palette of the rendered tiles
+}
+
+// Render renders the given slope of the flat tile. The flat tile is read from
+// the 97x49 pixels at the top left corner of its bounds; transparent pixels
+// are treated as black. Pixels without weight (see Pixel.Weight) are left
+// at index 0.
+func (r *Renderer) Render(slope int, flat image.Image) (*image.Paletted, error) {
+	if slope < 0 || slope >= len(r.Filtermaps) {
+		return nil, fmt.Errorf("filtermap: invalid slope %d", slope)
+	}
+	if r.ICM == nil {
+		return nil, fmt.Errorf("filtermap: no ICM")
+	}
+	fm := &r.Filtermaps[slope]
+
+	var light Lightmap
+	if slope < len(r.Lightmaps) {
+		light = r.Lightmaps[slope]
+		if len(light) != fm.NumPixels() {
+			return nil, fmt.Errorf("filtermap: slope %d: lightmap has %d pixels, want %d", slope, len(light), fm.NumPixels())
+		}
+	}
+
+	// read the flat tile once
+	origin := flat.Bounds().Min
+	src := make([][3]uint32, TilePixels)
+	for i, p := range FlatPixels() {
+		cr, cg, cb, _ := flat.At(origin.X+p.X, origin.Y+p.Y).RGBA()
+		src[i] = [3]uint32{cr >> 8, cg >> 8, cb >> 8}
+	}
+
+	img := image.NewPaletted(fm.Bounds(), r.Palette)
+	n := 0
+	for y, ln := range fm.Lines {
+		for x, px := range ln.Pixels {
+			row, err := r.ICM.Row(0)
+			if light != nil {
+				row, err = r.ICM.Row(light[n])
+			}
+			if err != nil {
+				return nil, fmt.Errorf("filtermap: slope %d: %w", slope, err)
+			}
+			n++
+
+			var sum [3]uint32
+			var weights uint32
+			for _, s := range px.Sources {
+				for k := range sum {
+					sum[k] += src[s.Index][k] * uint32(s.Weight)
+				}
+				weights += uint32(s.Weight)
+			}
+			if weights == 0 {
+				continue
+			}
+			idx := row.IndexRGB(uint8(sum[0]/weights), uint8(sum[1]/weights), uint8(sum[2]/weights))
+			img.SetColorIndex(ln.X+x, y, uint8(idx))
+		}
+	}
+	return img, nil
+}
+
+// RenderAll renders all slopes of the flat tile.
+func (r *Renderer) RenderAll(flat image.Image) ([]*image.Paletted, error) {
+	res := make([]*image.Paletted, len(r.Filtermaps))
+	for i := range res {
+		img, err := r.Render(i, flat)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = img
+	}
+	return res, nil
+}
@@ -0,0 +1,16 @@
+// Render DAT graphics.
+//
+// A graphic of the DAT file references an SLP by its DRS file ID. Its frames
+// are stored angle by angle: frame f of angle a is SLP frame
+// a*FrameCount+f. Graphics with a mirroring mode only store the angles of
+// the first half (south to north, counterclockwise), the other angles are
+// drawn mirrored.
+//
+// Graphics may have deltas: other graphics drawn at an offset relative to
+// the parent, e.g. the flags of a castle. A delta with graphic ID -1 draws
+// the SLP of the parent graphic itself.
+//
+// Player colors are those of the player passed to Resolver.Frame unless the
+// graphic has a PlayerColor other than -1, which is used for the graphic and
+// its deltas instead.
+package graphics
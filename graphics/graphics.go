@@ -0,0 +1,265 @@
+package graphics
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/slp"
+)
+
+// maxDepth limits the nesting of deltas.
+const maxDepth = 8
+
+var (
+	ErrGraphicNotFound = errors.New("graphics: graphic not found")
+	ErrSLPNotFound     = errors.New("graphics: SLP not found")
+	ErrFrameNotFound   = errors.New("graphics: frame not found")
+	ErrTooDeep         = errors.New("graphics: deltas nested too deep")
+	ErrNoPalettes      = errors.New("graphics: no palettes")
+)
+
+type (
+	// Frame is a composed frame of a graphic.
+	Frame struct {
+		Image *image.RGBA
+
+		// Hotspot is the position of the unit in Image, the image is
+		// drawn at the unit position minus the hotspot.
+		Hotspot image.Point
+	}
+
+	// Resolver renders the graphics of a DAT file with the SLPs of a set
+	// of DRS archives.
+	Resolver struct {
+		Data     *dat.File
+		Palettes slp.Palettes
+
+		// Archives are searched in order, the first archive containing
+		// an SLP wins.
+		Archives []*drs.Reader
+
+		slps map[drs.FileID]*slp.Reader
+	}
+
+	// layer is a single SLP frame positioned relative to the unit.
+	layer struct {
+		frame  *slp.Frame
+		offset image.Point // of the hotspot
+		mirror bool
+		player int
+	}
+)
+
+func NewResolver(data *dat.File, pals slp.Palettes, archives ...*drs.Reader) *Resolver {
+	return &Resolver{
+		Data:     data,
+		Palettes: pals,
+		Archives: archives,
+		slps:     make(map[drs.FileID]*slp.Reader),
+	}
+}
+
+// SLP returns the SLP with the given ID from the first archive that has it.
+func (r *Resolver) SLP(id drs.FileID) (*slp.Reader, error) {
+	if rd, found := r.slps[id]; found {
+		return rd, nil
+	}
+	for _, archive := range r.Archives {
+		for _, file := range archive.Files {
+			if file.ID != id {
+				continue
+			}
+			sr, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			rd, err := slp.New(sr, int64(file.Size))
+			if err != nil {
+				return nil, fmt.Errorf("graphics: SLP %s: %w", id, err)
+			}
+			if r.slps == nil {
+				r.slps = make(map[drs.FileID]*slp.Reader)
+			}
+			r.slps[id] = rd
+			return rd, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSLPNotFound, id)
+}
+
+func (r *Resolver) graphic(id int) (*dat.Graphic, error) {
+	g := r.Data.Graphic(id)
+	if g == nil {
+		return nil, fmt.Errorf("%w: %d", ErrGraphicNotFound, id)
+	}
+	return g, nil
+}
+
+// wrap returns v modulo n in the range [0, n), so negative angles and frames
+// count backwards.
+func wrap(v, n int) int {
+	return (v%n + n) % n
+}
+
+// storedAngle returns the angle stored in the SLP and whether it has to be
+// mirrored.
+func storedAngle(g *dat.Graphic, angle int) (int, bool) {
+	n := max(int(g.AngleCount), 1)
+	angle = wrap(angle, n)
+	if g.MirroringMode == 0 || angle <= n/2 {
+		return angle, false
+	}
+	return n - angle, true
+}
+
+// slpFrame returns the SLP frame of the graphic.
+func (r *Resolver) slpFrame(g *dat.Graphic, angle, frame int) (*slp.Frame, bool, error) {
+	rd, err := r.SLP(g.SLP)
+	if err != nil {
+		return nil, false, err
+	}
+	stored, mirror := storedAngle(g, angle)
+	frames := max(int(g.FrameCount), 1)
+	idx := stored*frames + wrap(frame, frames)
+	if idx >= len(rd.Frames) {
+		return nil, false, fmt.Errorf("%w: graphic %d, frame %d of %d", ErrFrameNotFound, g.ID, idx, len(rd.Frames))
+	}
+	return rd.Frames[idx], mirror, nil
+}
+
+// layers collects the SLP frames of g and its deltas in drawing order.
+// Graphics with a PlayerColor are drawn in that color, deltas without one
+// use the color of their parent.
+func (r *Resolver) layers(res []layer, g *dat.Graphic, angle, frame, player int, offset image.Point, depth int) ([]layer, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	if g.PlayerColor >= 0 {
+		player = int(g.PlayerColor)
+	}
+	if len(g.Deltas) == 0 {
+		if !g.SLP.IsValid() {
+			return res, nil
+		}
+		f, mirror, err := r.slpFrame(g, angle, frame)
+		if err != nil {
+			return nil, err
+		}
+		return append(res, layer{f, offset, mirror, player}), nil
+	}
+
+	_, mirrored := storedAngle(g, angle)
+	angles := max(int(g.AngleCount), 1)
+	angle = wrap(angle, angles)
+	for _, d := range g.Deltas {
+		if d.DisplayAngle >= 0 && int(d.DisplayAngle) != angle {
+			continue
+		}
+		off := image.Pt(int(d.OffsetX), int(d.OffsetY))
+		if mirrored {
+			off.X = -off.X
+		}
+		off = off.Add(offset)
+
+		if d.GraphicID < 0 {
+			// the parent SLP itself
+			f, mirror, err := r.slpFrame(g, angle, frame)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, layer{f, off, mirror, player})
+			continue
+		}
+		sub, err := r.graphic(int(d.GraphicID))
+		if err != nil {
+			return nil, err
+		}
+		// the delta may have a different number of angles
+		subAngle := angle * max(int(sub.AngleCount), 1) / angles
+		if res, err = r.layers(res, sub, subAngle, frame, player, off, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// bounds returns the rectangle of the layer relative to the unit position.
+func (l *layer) bounds() image.Rectangle {
+	hotspot := l.frame.Hotspot()
+	if l.mirror {
+		hotspot.X = int(l.frame.Width) - hotspot.X
+	}
+	return image.Rectangle{Max: l.frame.Size()}.Sub(hotspot).Add(l.offset)
+}
+
+// Frame renders frame of the given angle of the graphic, including its
+// deltas, with the colors of player. Graphics with a PlayerColor other than
+// -1 always use the colors of that player. Angles and frames wrap around, -1
+// is the last one. It returns ErrNoPalettes if the resolver has no palettes.
+func (r *Resolver) Frame(graphicID, angle, frame, player int) (*Frame, error) {
+	if r.Palettes == nil {
+		return nil, ErrNoPalettes
+	}
+	g, err := r.graphic(graphicID)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := r.layers(nil, g, angle, frame, player, image.Point{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var rect image.Rectangle
+	for i := range layers {
+		rect = rect.Union(layers[i].bounds())
+	}
+	res := &Frame{
+		Image:   image.NewRGBA(image.Rectangle{Max: rect.Size()}),
+		Hotspot: rect.Min.Mul(-1),
+	}
+	for i := range layers {
+		l := &layers[i]
+		img := image.NewRGBA(image.Rectangle{Max: l.frame.Size()})
+		if err := slp.DrawFrame(img, r.Palettes, l.frame, l.player, 0); err != nil {
+			return nil, err
+		}
+		if l.mirror {
+			mirror(img)
+		}
+		dst := l.bounds().Sub(rect.Min)
+		draw.Draw(res.Image, dst, img, image.Point{}, draw.Over)
+	}
+	return res, nil
+}
+
+// Animation renders all frames of the given angle of the graphic.
+func (r *Resolver) Animation(graphicID, angle, player int) ([]*Frame, error) {
+	g, err := r.graphic(graphicID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Frame, max(int(g.FrameCount), 1))
+	for i := range res {
+		if res[i], err = r.Frame(graphicID, angle, i, player); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// mirror flips img horizontally.
+func mirror(img *image.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):img.PixOffset(b.Max.X, y)]
+		for i, j := 0, len(row)-4; i < j; i, j = i+4, j-4 {
+			for k := 0; k < 4; k++ {
+				row[i+k], row[j+k] = row[j+k], row[i+k]
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package graphics_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/graphics"
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newResolver(t *testing.T) *graphics.Resolver {
+	archive := testutil.DRSReader(t, "slp", map[drs.FileID][]byte{
+		100: testutil.SLP(testutil.Frame{HotspotX: 2, HotspotY: 1, Rows: [][]byte{{5, 5, 5, 5}, {5, 5, 5, 5}}}),
+		101: testutil.SLP(
+			testutil.Frame{Rows: [][]byte{{7, 7}, {7, 7}}},
+			testutil.Frame{Rows: [][]byte{{8, 8}, {8, 8}}},
+		),
+		102: testutil.SLP(
+			testutil.Frame{Rows: [][]byte{{20, 21}}},
+			testutil.Frame{Rows: [][]byte{{10, 11}}},
+			testutil.Frame{Rows: [][]byte{{30, 31}}},
+		),
+		103: testutil.SLP(testutil.Frame{Rows: [][]byte{{16, 17}}, PlayerColor: true}),
+	})
+
+	f := &dat.File{Graphics: []*dat.Graphic{
+		{ID: 0, SLP: 100, FrameCount: 1, AngleCount: 1, PlayerColor: -1},
+		{ID: 1, SLP: 100, FrameCount: 1, AngleCount: 1, PlayerColor: -1, Deltas: []dat.GraphicDelta{
+			{GraphicID: -1, DisplayAngle: -1},
+			{GraphicID: 2, OffsetX: 3, OffsetY: -2, DisplayAngle: -1},
+		}},
+		{ID: 2, SLP: 101, FrameCount: 2, AngleCount: 1, PlayerColor: -1},
+		{ID: 3, SLP: 102, FrameCount: 1, AngleCount: 4, MirroringMode: 1, PlayerColor: -1},
+		{ID: 4, SLP: 999, FrameCount: 1, AngleCount: 1, PlayerColor: -1},
+		{ID: 5, SLP: 103, FrameCount: 1, AngleCount: 1, PlayerColor: -1},
+		{ID: 6, SLP: 103, FrameCount: 1, AngleCount: 1, PlayerColor: 2},
+		{ID: 7, SLP: 100, FrameCount: 1, AngleCount: 1, PlayerColor: 3, Deltas: []dat.GraphicDelta{
+			{GraphicID: 5, DisplayAngle: -1},
+			{GraphicID: 6, OffsetY: 1, DisplayAngle: -1},
+		}},
+	}}
+	return graphics.NewResolver(f, palette.NewSet(), archive)
+}
+
+func pal(i int) color.RGBA {
+	return color.RGBAModel.Convert(palette.Default[i]).(color.RGBA)
+}
+
+func TestFrame(t *testing.T) {
+	r := newResolver(t)
+	f, err := r.Frame(0, 0, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, image.Rect(0, 0, 4, 2), f.Image.Bounds())
+		assert.Equal(t, image.Pt(2, 1), f.Hotspot)
+		assert.Equal(t, pal(5), f.Image.RGBAAt(3, 1))
+	}
+}
+
+func TestFrameDeltas(t *testing.T) {
+	r := newResolver(t)
+	frames, err := r.Animation(1, 0, 1)
+	if !assert.NoError(t, err) || !assert.Len(t, frames, 1) {
+		return
+	}
+	f := frames[0]
+	// parent at (-2,-1)-(2,1), flag at (3,-2)-(5,0)
+	assert.Equal(t, image.Rect(0, 0, 7, 3), f.Image.Bounds())
+	assert.Equal(t, image.Pt(2, 2), f.Hotspot)
+	assert.Equal(t, pal(5), f.Image.RGBAAt(0, 1))
+	assert.Equal(t, pal(7), f.Image.RGBAAt(5, 0))
+	assert.Equal(t, color.RGBA{}, f.Image.RGBAAt(5, 2))
+
+	// the flag has two frames
+	f, err = r.Frame(1, 0, 1, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(8), f.Image.RGBAAt(5, 0))
+		assert.Equal(t, pal(5), f.Image.RGBAAt(0, 1))
+	}
+}
+
+func TestFrameMirrored(t *testing.T) {
+	r := newResolver(t)
+	f, err := r.Frame(3, 1, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(10), f.Image.RGBAAt(0, 0))
+		assert.Equal(t, image.Pt(0, 0), f.Hotspot)
+	}
+
+	// angle 3 is angle 1 mirrored
+	f, err = r.Frame(3, 3, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(11), f.Image.RGBAAt(0, 0))
+		assert.Equal(t, pal(10), f.Image.RGBAAt(1, 0))
+		assert.Equal(t, image.Pt(2, 0), f.Hotspot)
+	}
+}
+
+func TestFrameNegative(t *testing.T) {
+	r := newResolver(t)
+	// angle -1 is angle 3, angle 1 mirrored
+	f, err := r.Frame(3, -1, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(11), f.Image.RGBAAt(0, 0))
+		assert.Equal(t, pal(10), f.Image.RGBAAt(1, 0))
+	}
+
+	// frame -1 is the last frame of the flag
+	f, err = r.Frame(1, -5, -1, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(8), f.Image.RGBAAt(5, 0))
+	}
+}
+
+func TestFramePlayerColor(t *testing.T) {
+	r := newResolver(t)
+	stride := palette.DefaultPlayerColors.Stride
+	f, err := r.Frame(5, 0, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(16+stride), f.Image.RGBAAt(0, 0))
+		assert.Equal(t, pal(17+stride), f.Image.RGBAAt(1, 0))
+	}
+
+	// the graphic always uses player 2
+	f, err = r.Frame(6, 0, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(16+2*stride), f.Image.RGBAAt(0, 0))
+	}
+
+	// deltas without a player color use the one of the parent
+	f, err = r.Frame(7, 0, 0, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(16+3*stride), f.Image.RGBAAt(0, 0))
+		assert.Equal(t, pal(16+2*stride), f.Image.RGBAAt(0, 1))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	r := newResolver(t)
+	_, err := r.Frame(8, 0, 0, 1)
+	assert.ErrorIs(t, err, graphics.ErrGraphicNotFound)
+	_, err = r.Frame(4, 0, 0, 1)
+	assert.ErrorIs(t, err, graphics.ErrSLPNotFound)
+
+	r.Palettes = nil
+	_, err = r.Frame(0, 0, 0, 1)
+	assert.ErrorIs(t, err, graphics.ErrNoPalettes)
+}
@@ -0,0 +1,166 @@
+package icm
+
+import (
+	"errors"
+	"image/color"
+	"math"
+)
+
+var (
+	ErrNoColors = errors.New("icm: palette has no usable colors")
+)
+
+type (
+	// Level is a lighting level. The saturation and value of a color are
+	// multiplied by S and V (clamped to [0, 1]).
+	Level struct {
+		S, V float64
+	}
+
+	GenerateOptions struct {
+		// Levels are the lighting levels of the rows 1-9. Row 0 is
+		// always the neutral map.
+		Levels [NumRows - 1]Level
+
+		// Reserved is the number of system reserved colors at the
+		// beginning and the end of the palette. They are never used.
+		Reserved int
+	}
+)
+
+// DefaultGenerateOptions are the options used by Generate if none are given:
+// four steps of darkening, a neutral level and four steps of brightening.
+var DefaultGenerateOptions = GenerateOptions{
+	Levels: [NumRows - 1]Level{
+		{S: 1.2, V: 0.6},
+		{S: 1.15, V: 0.7},
+		{S: 1.1, V: 0.8},
+		{S: 1.05, V: 0.9},
+		{S: 1, V: 1},
+		{S: 0.95, V: 1.1},
+		{S: 0.9, V: 1.2},
+		{S: 0.85, V: 1.3},
+		{S: 0.8, V: 1.4},
+	},
+	Reserved: 10,
+}
+
+// axis returns the 8 bit channel value of a 5 bit map coordinate.
+func axis(i int) uint8 {
+	return uint8(i<<3 | i>>2)
+}
+
+// Generate builds an inverse color map for pal.
+//
+// Row 0 maps every color to the nearest palette color. For the other rows
+// the color is converted to HSV, modified by the lighting level and
+// converted back before it is looked up in row 0.
+func Generate(pal color.Palette, opts *GenerateOptions) (m Map, err error) {
+	if opts == nil {
+		opts = &DefaultGenerateOptions
+	}
+
+	type entry struct {
+		index   int
+		r, g, b int32
+	}
+	var colors []entry
+	for i := opts.Reserved; i < len(pal)-opts.Reserved; i++ {
+		r, g, b, _ := pal[i].RGBA()
+		colors = append(colors, entry{i, int32(r >> 8), int32(g >> 8), int32(b >> 8)})
+	}
+	if len(colors) == 0 {
+		return m, ErrNoColors
+	}
+
+	nearest := func(r, g, b uint8) byte {
+		best, bestDist := 0, int32(math.MaxInt32)
+		for _, c := range colors {
+			dr, dg, db := c.r-int32(r), c.g-int32(g), c.b-int32(b)
+			if d := dr*dr + dg*dg + db*db; d < bestDist {
+				best, bestDist = c.index, d
+			}
+		}
+		return byte(best)
+	}
+
+	for r := 0; r < 32; r++ {
+		for g := 0; g < 32; g++ {
+			for b := 0; b < 32; b++ {
+				m[0][r][g][b] = nearest(axis(r), axis(g), axis(b))
+			}
+		}
+	}
+
+	for row, level := range opts.Levels {
+		for r := 0; r < 32; r++ {
+			for g := 0; g < 32; g++ {
+				for b := 0; b < 32; b++ {
+					h, s, v := rgbToHSV(axis(r), axis(g), axis(b))
+					s = clamp01(s * level.S)
+					v = clamp01(v * level.V)
+					lr, lg, lb := hsvToRGB(h, s, v)
+					m[row+1][r][g][b] = m[0][lr>>3][lg>>3][lb>>3]
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// rgbToHSV returns hue in degrees [0, 360), saturation and value in [0, 1].
+func rgbToHSV(r, g, b uint8) (h, s, v float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	hi := math.Max(rf, math.Max(gf, bf))
+	lo := math.Min(rf, math.Min(gf, bf))
+	d := hi - lo
+
+	v = hi
+	if hi > 0 {
+		s = d / hi
+	}
+	switch {
+	case d == 0:
+		h = 0
+	case hi == rf:
+		h = 60 * math.Mod((gf-bf)/d, 6)
+	case hi == gf:
+		h = 60 * ((bf-rf)/d + 2)
+	default:
+		h = 60 * ((rf-gf)/d + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return
+}
+
+func hsvToRGB(h, s, v float64) (r, g, b uint8) {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var rf, gf, bf float64
+	switch {
+	case h < 60:
+		rf, gf, bf = c, x, 0
+	case h < 120:
+		rf, gf, bf = x, c, 0
+	case h < 180:
+		rf, gf, bf = 0, c, x
+	case h < 240:
+		rf, gf, bf = 0, x, c
+	case h < 300:
+		rf, gf, bf = x, 0, c
+	default:
+		rf, gf, bf = c, 0, x
+	}
+	conv := func(f float64) uint8 {
+		return uint8(math.Round(clamp01(f+m) * 255))
+	}
+	return conv(rf), conv(gf), conv(bf)
+}
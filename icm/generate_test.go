@@ -0,0 +1,74 @@
+package icm_test
+
+import (
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// grey returns the grey of the 5 bit map coordinate i.
+func grey(i int) color.RGBA {
+	v := uint8(i<<3 | i>>2)
+	return color.RGBA{v, v, v, 255}
+}
+
+func luma(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return r + g + b
+}
+
+func TestGenerate(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	// two reserved reds at both ends and greys in between
+	pal := color.Palette{red, red}
+	for i := 0; i < 32; i += 2 {
+		pal = append(pal, grey(i))
+	}
+	pal = append(pal, grey(31), red, red)
+
+	m, err := icm.Generate(pal, &icm.GenerateOptions{
+		Levels:   icm.DefaultGenerateOptions.Levels,
+		Reserved: 2,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// row 0 picks the nearest color
+	for i := 2; i < len(pal)-2; i++ {
+		c := pal[i].(color.RGBA)
+		assert.Equal(t, i, m[0].IndexRGB(c.R, c.G, c.B), "color %d", i)
+	}
+	assert.Equal(t, 2+6, m[0].IndexRGB(100, 100, 100))
+
+	// the reserved colors are never used, not even for red
+	for row := range m {
+		for r := range m[row] {
+			for g := range m[row][r] {
+				for _, idx := range m[row][r][g] {
+					assert.True(t, idx >= 2 && int(idx) < len(pal)-2)
+				}
+			}
+		}
+	}
+
+	// rows 1-4 darken, 6-9 brighten
+	mid := grey(16)
+	neutral := luma(pal[m[5].IndexRGB(mid.R, mid.G, mid.B)])
+	assert.Equal(t, luma(mid), neutral)
+	for row := uint8(1); row <= 4; row++ {
+		assert.Less(t, luma(pal[m[row].IndexRGB(mid.R, mid.G, mid.B)]), neutral, "row %d", row)
+	}
+	for row := uint8(6); row <= 9; row++ {
+		assert.Greater(t, luma(pal[m[row].IndexRGB(mid.R, mid.G, mid.B)]), neutral, "row %d", row)
+	}
+	assert.Less(t, luma(pal[m[1].IndexRGB(mid.R, mid.G, mid.B)]), luma(pal[m[4].IndexRGB(mid.R, mid.G, mid.B)]))
+}
+
+func TestGenerateNoColors(t *testing.T) {
+	_, err := icm.Generate(color.Palette{color.Black, color.White}, &icm.GenerateOptions{Reserved: 1})
+	assert.ErrorIs(t, err, icm.ErrNoColors)
+}
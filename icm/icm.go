@@ -2,6 +2,7 @@ package icm
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"image/color"
 	"io"
@@ -9,11 +10,19 @@ import (
 )
 
 const (
-	numRows = 10 // number of brightness levels
+	NumRows = 10 // number of brightness levels
+	RowSize = 32 * 32 * 32
+)
+
+var (
+	ErrInvalidBrightness = errors.New("icm: invalid brightness")
 )
 
 type (
-	Map [numRows][32][32][32]byte
+	// Row maps the upper 5 bits of r, g and b to a palette index.
+	Row [32][32][32]byte
+
+	Map [NumRows]Row
 )
 
 func Open(filename string) (f Map, err error) {
@@ -42,10 +51,94 @@ func (f *Map) Load(rd io.Reader) error {
 	return nil
 }
 
+// WriteTo writes the map in the format of view_icm.dat.
+func (f *Map) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	buf := make([]byte, RowSize)
+	for i := range f {
+		f[i].bytes(buf)
+		m, err := w.Write(buf)
+		n += int64(m)
+		if err != nil {
+			return n, fmt.Errorf("icm: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// Row returns the lookup table for the given brightness.
+func (f *Map) Row(brightness uint8) (*Row, error) {
+	if brightness >= NumRows {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidBrightness, brightness)
+	}
+	return &f[brightness], nil
+}
+
+// IndexRGB returns the palette index for the given 8 bit color. The
+// brightness is not checked, use Row to validate it once outside of loops.
+func (f *Map) IndexRGB(brightness, r, g, b uint8) int {
+	return f[brightness].IndexRGB(r, g, b)
+}
+
+// Index returns the palette index for c. The brightness is not checked, see
+// CheckedIndex. Index copies the map, use Row or CheckedIndex in loops.
 func (f Map) Index(brightness uint8, c color.Color) int {
+	return f[brightness].Index(c)
+}
+
+// CheckedIndex is like Index but returns an error for an invalid
+// brightness.
+func (f *Map) CheckedIndex(brightness uint8, c color.Color) (int, error) {
+	if brightness >= NumRows {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidBrightness, brightness)
+	}
+	return f[brightness].Index(c), nil
+}
+
+// Index returns the palette index for c, using the upper 5 bits of each
+// channel.
+func (row *Row) Index(c color.Color) int {
 	r, g, b, _ := c.RGBA()
-	r = (r >> 10) & 0xff
-	g = (g >> 10) & 0xff
-	b = (b >> 10) & 0xff
-	return int(f[brightness][r][g][b])
+	return int(row[r>>11][g>>11][b>>11])
+}
+
+// IndexRGB returns the palette index for the given 8 bit color.
+func (row *Row) IndexRGB(r, g, b uint8) int {
+	return int(row[r>>3][g>>3][b>>3])
+}
+
+// bytes copies the row into buf, which must have RowSize bytes.
+func (row *Row) bytes(buf []byte) {
+	for r := range row {
+		for g := range row[r] {
+			copy(buf[(r*32+g)*32:], row[r][g][:])
+		}
+	}
+}
+
+// Model converts colors to the colors of a palette using a row of an ICM.
+type Model struct {
+	Row     *Row
+	Palette color.Palette
+}
+
+var _ color.Model = Model{}
+
+// Model returns the color model for the given brightness and palette.
+func (f *Map) Model(brightness uint8, pal color.Palette) (Model, error) {
+	row, err := f.Row(brightness)
+	if err != nil {
+		return Model{}, err
+	}
+	return Model{Row: row, Palette: pal}, nil
+}
+
+// Convert returns the palette color for c.
+func (m Model) Convert(c color.Color) color.Color {
+	return m.Palette[m.Row.Index(c)]
+}
+
+// Index returns the palette index for c.
+func (m Model) Index(c color.Color) int {
+	return m.Row.Index(c)
 }
@@ -0,0 +1,89 @@
+package icm_test
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMap returns a map where every entry encodes its coordinates.
+func newMap() *icm.Map {
+	var m icm.Map
+	for i := range m {
+		for r := range m[i] {
+			for g := range m[i][r] {
+				for b := range m[i][r][g] {
+					m[i][r][g][b] = byte(i*7 + r + g*2 + b*3)
+				}
+			}
+		}
+	}
+	return &m
+}
+
+func want(i, r, g, b int) int {
+	return int(byte(i*7 + r + g*2 + b*3))
+}
+
+func TestIndex(t *testing.T) {
+	m := newMap()
+
+	for _, tc := range []struct {
+		c       color.RGBA
+		r, g, b int
+	}{
+		{color.RGBA{0, 0, 0, 255}, 0, 0, 0},
+		{color.RGBA{255, 255, 255, 255}, 31, 31, 31},
+		{color.RGBA{7, 8, 15, 255}, 0, 1, 1},
+		{color.RGBA{128, 64, 248, 255}, 16, 8, 31},
+	} {
+		for i := uint8(0); i < icm.NumRows; i++ {
+			idx, err := m.CheckedIndex(i, tc.c)
+			if assert.NoError(t, err) {
+				assert.Equal(t, want(int(i), tc.r, tc.g, tc.b), idx, "row %d, color %v", i, tc.c)
+			}
+			assert.Equal(t, idx, m.Index(i, tc.c))
+			assert.Equal(t, want(int(i), tc.r, tc.g, tc.b), m.IndexRGB(i, tc.c.R, tc.c.G, tc.c.B))
+		}
+	}
+
+	// Index works on map values
+	assert.Equal(t, want(2, 0, 0, 0), icm.Map(*m).Index(2, color.Black))
+
+	_, err := m.CheckedIndex(icm.NumRows, color.Black)
+	assert.ErrorIs(t, err, icm.ErrInvalidBrightness)
+	_, err = m.Row(icm.NumRows)
+	assert.ErrorIs(t, err, icm.ErrInvalidBrightness)
+}
+
+func TestModel(t *testing.T) {
+	m := newMap()
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{R: uint8(i), A: 255}
+	}
+
+	model, err := m.Model(3, pal)
+	if assert.NoError(t, err) {
+		c := color.RGBA{R: 16, G: 32, B: 48, A: 255}
+		assert.Equal(t, want(3, 2, 4, 6), model.Index(c))
+		assert.Equal(t, pal[want(3, 2, 4, 6)], model.Convert(c))
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	m := newMap()
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(icm.NumRows*icm.RowSize), n)
+	}
+	res, err := icm.New(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, *m, res)
+	}
+}
@@ -0,0 +1,296 @@
+// Package codec reads and writes little endian structs described by struct
+// tags. It is used for the DAT and scenario files, see the package
+// documentation of dat for the tag syntax.
+//
+// Conditions and lengths may reference sibling fields or variables ($Var).
+// Variables are predefined by the caller or set by fields with a var tag.
+// Floats are stored in variables multiplied by 100, e.g. 1.22 becomes 122.
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const maxLength = 1 << 20 // sanity limit for lengths read from the file
+
+var (
+	ErrInvalidLength  = errors.New("invalid length")
+	ErrLengthMismatch = errors.New("length mismatch")
+	ErrUndefinedVar   = errors.New("undefined variable")
+)
+
+type (
+	// Config is the configuration of a Decoder or Encoder.
+	Config struct {
+		// Vars are the predefined variables.
+		Vars map[string]int
+
+		// Version reports whether a field with the tag ver=expr exists.
+		// Fields with a ver tag always exist if it is nil.
+		Version func(expr string) bool
+	}
+
+	// ref references a sibling field, an element of a sibling field
+	// ("Field[4]") or a variable ("$Var").
+	ref struct {
+		name  string
+		index int // -1 if the whole field is referenced
+		isVar bool
+	}
+
+	cond struct {
+		ref   ref
+		op    string
+		value int
+	}
+
+	fieldInfo struct {
+		name  string
+		index int
+
+		length  *ref   // len=
+		ptrs    string // ptrs=
+		cond    []cond // if=
+		setVar  string // var=
+		version string // ver=
+	}
+
+	structInfo struct {
+		fields []fieldInfo
+	}
+)
+
+var typeCache sync.Map // reflect.Type -> *structInfo
+
+func parseRef(s string) (ref, error) {
+	r := ref{name: s, index: -1}
+	if name, isVar := strings.CutPrefix(s, "$"); isVar {
+		r.name, r.isVar = name, true
+	} else if name, idx, found := strings.Cut(s, "["); found {
+		n, err := strconv.Atoi(strings.TrimSuffix(idx, "]"))
+		if err != nil || !strings.HasSuffix(idx, "]") {
+			return r, fmt.Errorf("invalid reference %q", s)
+		}
+		r.name, r.index = name, n
+	}
+	if r.name == "" {
+		return r, fmt.Errorf("invalid reference %q", s)
+	}
+	return r, nil
+}
+
+func parseCond(expr string) ([]cond, error) {
+	var res []cond
+	for _, part := range strings.Split(expr, "&") {
+		i := strings.IndexAny(part, "=!<>")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid condition %q", part)
+		}
+		j := i
+		for j < len(part) && strings.IndexByte("=!<>", part[j]) >= 0 {
+			j++
+		}
+		op := part[i:j]
+		switch op {
+		case "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("invalid operator in %q", part)
+		}
+		v, err := strconv.Atoi(part[j:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %w", part, err)
+		}
+		r, err := parseRef(part[:i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, cond{ref: r, op: op, value: v})
+	}
+	return res, nil
+}
+
+func getStructInfo(t reflect.Type) *structInfo {
+	if info, found := typeCache.Load(t); found {
+		return info.(*structInfo)
+	}
+	info := &structInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		f := fieldInfo{name: sf.Name, index: i}
+		tag := sf.Tag.Get("bin")
+		if tag == "-" {
+			continue
+		}
+		fail := func(err error) {
+			panic(fmt.Errorf("codec: %s.%s: %w", t.Name(), sf.Name, err))
+		}
+		for _, opt := range strings.Split(tag, ",") {
+			if opt == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(opt, "=")
+			switch key {
+			case "len":
+				r, err := parseRef(value)
+				if err != nil {
+					fail(err)
+				}
+				f.length = &r
+			case "ptrs":
+				f.ptrs = value
+			case "var":
+				f.setVar = value
+			case "ver":
+				f.version = value
+			case "if":
+				c, err := parseCond(value)
+				if err != nil {
+					fail(err)
+				}
+				f.cond = c
+			default:
+				fail(fmt.Errorf("unknown tag option %q", opt))
+			}
+		}
+		info.fields = append(info.fields, f)
+	}
+	typeCache.Store(t, info)
+	return info
+}
+
+// intValue returns the value of an integer field.
+func intValue(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return int(v.Int())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return int(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int(math.Round(v.Float() * 100))
+	}
+	panic(fmt.Errorf("codec: %s is not a number", v.Type()))
+}
+
+func setInt(v reflect.Value, n int) {
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		v.SetInt(int64(n))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		v.SetUint(uint64(n))
+	default:
+		panic(fmt.Errorf("codec: %s is not an integer", v.Type()))
+	}
+}
+
+// state is shared by Decoder and Encoder.
+type state struct {
+	vars     map[string]int
+	version  func(string) bool
+	versions map[string]bool // cache of version
+}
+
+func newState(cfg Config) state {
+	s := state{
+		vars:     make(map[string]int),
+		version:  cfg.Version,
+		versions: make(map[string]bool),
+	}
+	for k, v := range cfg.Vars {
+		s.vars[k] = v
+	}
+	return s
+}
+
+// Var returns the value of a variable.
+func (s *state) Var(name string) (int, bool) {
+	v, found := s.vars[name]
+	return v, found
+}
+
+// field returns the referenced sibling field or element.
+func (r *ref) field(parent reflect.Value) reflect.Value {
+	v := parent.FieldByName(r.name)
+	if r.index >= 0 {
+		if r.index >= v.Len() {
+			return reflect.Value{}
+		}
+		v = v.Index(r.index)
+	}
+	return v
+}
+
+func (s *state) resolve(r *ref, parent reflect.Value) (int, error) {
+	if r.isVar {
+		n, found := s.vars[r.name]
+		if !found {
+			return 0, fmt.Errorf("%w: $%s", ErrUndefinedVar, r.name)
+		}
+		return n, nil
+	}
+	v := r.field(parent)
+	if !v.IsValid() {
+		return 0, nil
+	}
+	return intValue(v), nil
+}
+
+// present returns true if the field exists in the version and the
+// conditions of the field hold.
+func (s *state) present(f *fieldInfo, parent reflect.Value) (bool, error) {
+	if f.version != "" && s.version != nil {
+		ok, found := s.versions[f.version]
+		if !found {
+			ok = s.version(f.version)
+			s.versions[f.version] = ok
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	for _, c := range f.cond {
+		a, err := s.resolve(&c.ref, parent)
+		if err != nil {
+			return false, err
+		}
+		var ok bool
+		switch c.op {
+		case "==":
+			ok = a == c.value
+		case "!=":
+			ok = a != c.value
+		case "<":
+			ok = a < c.value
+		case "<=":
+			ok = a <= c.value
+		case ">":
+			ok = a > c.value
+		case ">=":
+			ok = a >= c.value
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// length returns the length of a field, negative lengths are treated as 0.
+func (s *state) length(f *fieldInfo, parent reflect.Value) (int, error) {
+	n, err := s.resolve(f.length, parent)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxLength {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidLength, n)
+	}
+	return max(n, 0), nil
+}
@@ -0,0 +1,202 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Decoder reads values from an input stream.
+type Decoder struct {
+	state
+
+	r   io.Reader
+	buf [8]byte
+}
+
+func NewDecoder(r io.Reader, cfg Config) *Decoder {
+	return &Decoder{state: newState(cfg), r: r}
+}
+
+// Decode reads the value pointed to by v.
+func (d *Decoder) Decode(v any) error {
+	return d.value(reflect.ValueOf(v).Elem())
+}
+
+// DecodeFrom reads the fields of the struct pointed to by v, starting with
+// field start.
+func (d *Decoder) DecodeFrom(v any, start int) error {
+	return d.structFrom(reflect.ValueOf(v).Elem(), start)
+}
+
+// readFull reads len(b) bytes. The input never ends inside of a value,
+// io.EOF is reported as io.ErrUnexpectedEOF.
+func (d *Decoder) readFull(b []byte) error {
+	_, err := io.ReadFull(d.r, b)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (d *Decoder) read(n int) ([]byte, error) {
+	b := d.buf[:n]
+	return b, d.readFull(b)
+}
+
+func (d *Decoder) value(v reflect.Value) error {
+	le := binary.LittleEndian
+	switch v.Kind() {
+	case reflect.Int8:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(int8(b[0])))
+	case reflect.Uint8:
+		b, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(b[0]))
+	case reflect.Int16:
+		b, err := d.read(2)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(int16(le.Uint16(b))))
+	case reflect.Uint16:
+		b, err := d.read(2)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(le.Uint16(b)))
+	case reflect.Int32:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(int32(le.Uint32(b))))
+	case reflect.Uint32:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(le.Uint32(b)))
+	case reflect.Float32:
+		b, err := d.read(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(le.Uint32(b))))
+	case reflect.Float64:
+		b, err := d.read(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(le.Uint64(b)))
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return d.readFull(v.Slice(0, v.Len()).Bytes())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := d.value(v.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case reflect.Struct:
+		return d.structFrom(v, 0)
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.value(v.Elem())
+	default:
+		panic(fmt.Errorf("codec: unsupported type %s", v.Type()))
+	}
+	return nil
+}
+
+// structFrom decodes the fields of v, starting with field start.
+func (d *Decoder) structFrom(v reflect.Value, start int) error {
+	info := getStructInfo(v.Type())
+	for i := start; i < len(info.fields); i++ {
+		f := &info.fields[i]
+		fv := v.Field(f.index)
+		ok, err := d.present(f, v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case f.ptrs != "":
+			err = d.pointers(fv, v.FieldByName(f.ptrs))
+		case f.length != nil:
+			err = d.sized(f, fv, v)
+		default:
+			err = d.value(fv)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		if f.setVar != "" {
+			d.vars[f.setVar] = intValue(fv)
+		}
+	}
+	return nil
+}
+
+// pointers decodes a slice of pointers, element i exists if ptrs[i] != 0.
+func (d *Decoder) pointers(fv, ptrs reflect.Value) error {
+	n := ptrs.Len()
+	s := reflect.MakeSlice(fv.Type(), n, n)
+	for i := 0; i < n; i++ {
+		if intValue(ptrs.Index(i)) == 0 {
+			continue
+		}
+		if err := d.value(s.Index(i)); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	fv.Set(s)
+	return nil
+}
+
+// sized decodes a slice or string whose length is given by the len tag.
+func (d *Decoder) sized(f *fieldInfo, fv, parent reflect.Value) error {
+	n, err := d.length(f, parent)
+	if err != nil {
+		return err
+	}
+
+	if fv.Kind() == reflect.String {
+		b := make([]byte, n)
+		if err := d.readFull(b); err != nil {
+			return err
+		}
+		fv.SetString(string(b))
+		return nil
+	}
+
+	s := reflect.MakeSlice(fv.Type(), n, n)
+	if s.Type().Elem().Kind() == reflect.Uint8 {
+		if err := d.readFull(s.Bytes()); err != nil {
+			return err
+		}
+		fv.Set(s)
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		if err := d.value(s.Index(i)); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	fv.Set(s)
+	return nil
+}
@@ -0,0 +1,244 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Encoder writes values to an output stream.
+//
+// Fields referenced by len and ptrs tags are updated from the slices before
+// a struct is written. A count is left unchanged if it already matches,
+// negative counts match empty slices.
+type Encoder struct {
+	state
+
+	w   io.Writer
+	buf [8]byte
+}
+
+func NewEncoder(w io.Writer, cfg Config) *Encoder {
+	return &Encoder{state: newState(cfg), w: w}
+}
+
+// Encode writes the value pointed to by v.
+func (e *Encoder) Encode(v any) error {
+	return e.value(reflect.ValueOf(v).Elem())
+}
+
+// EncodeFrom writes the fields of the struct pointed to by v, starting with
+// field start. All count fields are updated, including the skipped ones.
+func (e *Encoder) EncodeFrom(v any, start int) error {
+	return e.structFrom(reflect.ValueOf(v).Elem(), start)
+}
+
+func (e *Encoder) write(b []byte) error {
+	_, err := e.w.Write(b)
+	return err
+}
+
+func (e *Encoder) value(v reflect.Value) error {
+	le := binary.LittleEndian
+	b := e.buf[:]
+	switch v.Kind() {
+	case reflect.Int8, reflect.Uint8:
+		b[0] = byte(intValue(v))
+		return e.write(b[:1])
+	case reflect.Int16, reflect.Uint16:
+		le.PutUint16(b, uint16(intValue(v)))
+		return e.write(b[:2])
+	case reflect.Int32, reflect.Uint32:
+		le.PutUint32(b, uint32(intValue(v)))
+		return e.write(b[:4])
+	case reflect.Float32:
+		le.PutUint32(b, math.Float32bits(float32(v.Float())))
+		return e.write(b[:4])
+	case reflect.Float64:
+		le.PutUint64(b, math.Float64bits(v.Float()))
+		return e.write(b[:8])
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			tmp := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(tmp), v)
+			return e.write(tmp)
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.value(v.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return e.structValue(v)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return e.value(reflect.New(v.Type().Elem()).Elem())
+		}
+		return e.value(v.Elem())
+	}
+	panic(fmt.Errorf("codec: unsupported type %s", v.Type()))
+}
+
+// setCount sets the referenced count to n unless it already matches.
+func setCount(r *ref, parent reflect.Value, n int) {
+	v := r.field(parent)
+	if !v.IsValid() {
+		return
+	}
+	if max(intValue(v), 0) != n {
+		setInt(v, n)
+	}
+}
+
+// sync updates the count and pointer fields of v to match the lengths of
+// the slices that depend on them.
+func (e *Encoder) sync(v reflect.Value, info *structInfo) error {
+	synced := make(map[ref]int)
+	check := func(f *fieldInfo, r ref, n int) error {
+		if prev, found := synced[r]; found && prev != n {
+			return fmt.Errorf("%w: %s has %d elements, want %d", ErrLengthMismatch, f.name, n, prev)
+		}
+		synced[r] = n
+		return nil
+	}
+
+	// pointer fields first, the pointer array itself has a count field
+	for i := range info.fields {
+		f := &info.fields[i]
+		if f.ptrs == "" {
+			continue
+		}
+		ok, err := e.present(f, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		fv := v.Field(f.index)
+		ptrs := v.FieldByName(f.ptrs)
+		n := fv.Len()
+		if err := check(f, ref{name: f.ptrs, index: -1}, n); err != nil {
+			return err
+		}
+		if ptrs.Len() != n {
+			s := reflect.MakeSlice(ptrs.Type(), n, n)
+			reflect.Copy(s, ptrs)
+			ptrs.Set(s)
+		}
+		for j := 0; j < n; j++ {
+			p := ptrs.Index(j)
+			switch {
+			case fv.Index(j).IsNil():
+				setInt(p, 0)
+			case intValue(p) == 0:
+				setInt(p, 1)
+			}
+		}
+	}
+
+	for i := range info.fields {
+		f := &info.fields[i]
+		if f.length == nil || f.length.isVar {
+			continue
+		}
+		ok, err := e.present(f, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		n := v.Field(f.index).Len()
+		if err := check(f, *f.length, n); err != nil {
+			return err
+		}
+		setCount(f.length, v, n)
+	}
+	return nil
+}
+
+func (e *Encoder) structValue(v reflect.Value) error {
+	return e.structFrom(v, 0)
+}
+
+// structFrom encodes the fields of v, starting with field start.
+func (e *Encoder) structFrom(v reflect.Value, start int) error {
+	info := getStructInfo(v.Type())
+	if err := e.sync(v, info); err != nil {
+		return err
+	}
+	for i := start; i < len(info.fields); i++ {
+		f := &info.fields[i]
+		fv := v.Field(f.index)
+		ok, err := e.present(f, v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case f.ptrs != "":
+			err = e.pointers(fv)
+		case f.length != nil:
+			err = e.sized(f, fv)
+		default:
+			err = e.value(fv)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		if f.setVar != "" {
+			e.vars[f.setVar] = intValue(fv)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) pointers(fv reflect.Value) error {
+	for i := 0; i < fv.Len(); i++ {
+		if fv.Index(i).IsNil() {
+			continue
+		}
+		if err := e.value(fv.Index(i)); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) sized(f *fieldInfo, fv reflect.Value) error {
+	if f.length.isVar {
+		n, err := e.resolve(f.length, reflect.Value{})
+		if err != nil {
+			return err
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 && fv.Len() <= n {
+			// fixed size names are padded with NUL
+			b := make([]byte, n)
+			copy(b, fv.Bytes())
+			return e.write(b)
+		}
+		if fv.Len() != n {
+			return fmt.Errorf("%w: %d elements, want $%s = %d", ErrLengthMismatch, fv.Len(), f.length.name, n)
+		}
+	}
+	if fv.Kind() == reflect.String {
+		return e.write([]byte(fv.String()))
+	}
+	if fv.Type().Elem().Kind() == reflect.Uint8 {
+		return e.write(fv.Bytes())
+	}
+	for i := 0; i < fv.Len(); i++ {
+		if err := e.value(fv.Index(i)); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
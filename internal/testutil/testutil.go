@@ -0,0 +1,200 @@
+// Package testutil builds small DRS archives and SLP files for tests and
+// locates files of the game.
+package testutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/slp"
+)
+
+// Frame is a frame of an SLP built by SLP.
+type Frame struct {
+	HotspotX, HotspotY int
+	Properties         uint32
+
+	// Rows are the palette indices of every row. Left is the number of
+	// transparent pixels in front of each row (optional), Width the width
+	// of the frame (defaults to the longest row).
+	Rows  [][]byte
+	Left  []int
+	Width int
+
+	// PlayerColor draws the rows with player color commands, the indices
+	// are those of player 0.
+	PlayerColor bool
+}
+
+// GameDirEnv is the environment variable pointing to a directory with files
+// of the game. The files are not distributed with the tests.
+const GameDirEnv = "GENIE_TESTDATA"
+
+// GameFile returns the path of the game file name in the directory
+// GameDirEnv points to. The test is skipped if the file does not exist.
+func GameFile(t testing.TB, name string) string {
+	t.Helper()
+	dir := os.Getenv(GameDirEnv)
+	if dir == "" {
+		t.Skipf("%s is not set", GameDirEnv)
+	}
+	filename := filepath.Join(dir, name)
+	if _, err := os.Stat(filename); errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("%s does not exist", filename)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// GameFiles returns the game files matching any of the patterns (see
+// filepath.Match) in the directory GameDirEnv points to. The test is
+// skipped if there are none.
+func GameFiles(t testing.TB, patterns ...string) []string {
+	t.Helper()
+	dir := os.Getenv(GameDirEnv)
+	if dir == "" {
+		t.Skipf("%s is not set", GameDirEnv)
+	}
+	var res []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, matches...)
+	}
+	if len(res) == 0 {
+		t.Skipf("no %v in %s", patterns, dir)
+	}
+	return res
+}
+
+// DRS returns a DRS archive with a single table of the given extension
+// (e.g. "slp") containing files.
+func DRS(ext string, files map[drs.FileID][]byte) []byte {
+	le := binary.LittleEndian
+	var hdr drs.Header
+	copy(hdr.Copyright[:], "Copyright (c) 1997 Ensemble Studios.")
+	copy(hdr.Version[:], "1.00")
+	copy(hdr.Ftype[:], "tribe")
+	hdr.TableCount = 1
+
+	tableOffset := int32(binary.Size(hdr))
+	fileInfoOffset := tableOffset + int32(binary.Size(drs.TableInfo{}))
+	dataOffset := fileInfoOffset + int32(len(files)*binary.Size(drs.FileInfo{}))
+	hdr.FileOffset = dataOffset
+
+	ids := make([]drs.FileID, 0, len(files))
+	for id := range files {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var infos []drs.FileInfo
+	var data bytes.Buffer
+	for _, id := range ids {
+		infos = append(infos, drs.FileInfo{ID: id, Offset: dataOffset + int32(data.Len()), Size: int32(len(files[id]))})
+		data.Write(files[id])
+	}
+
+	// the extension is stored reversed and padded with spaces
+	var table drs.TableInfo
+	for i := range table.FileExtension {
+		table.FileExtension[i] = ' '
+	}
+	for i := 0; i < len(ext) && i < len(table.FileExtension); i++ {
+		table.FileExtension[len(table.FileExtension)-1-i] = ext[i]
+	}
+	table.Offset = fileInfoOffset
+	table.NumFiles = int32(len(infos))
+
+	var buf bytes.Buffer
+	binary.Write(&buf, le, hdr)
+	binary.Write(&buf, le, table)
+	binary.Write(&buf, le, infos)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+// DRSReader returns a reader for the archive built by DRS.
+func DRSReader(t testing.TB, ext string, files map[drs.FileID][]byte) *drs.Reader {
+	t.Helper()
+	data := DRS(ext, files)
+	rd, err := drs.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rd
+}
+
+// SLP returns an SLP file with the given frames. The pixels are stored with
+// lesser or greater draw commands or player color commands. Player color
+// rows must be shorter than 256 pixels.
+func SLP(frames ...Frame) []byte {
+	le := binary.LittleEndian
+	hdr := slp.Header{NumFrames: int32(len(frames))}
+	copy(hdr.Version[:], "2.0N")
+
+	offset := binary.Size(hdr) + len(frames)*binary.Size(slp.FrameInfo{})
+	var infos []slp.FrameInfo
+	var data bytes.Buffer
+	for _, f := range frames {
+		h := len(f.Rows)
+		width := f.Width
+		for y, row := range f.Rows {
+			width = max(width, left(f, y)+len(row))
+		}
+		info := slp.FrameInfo{
+			OutlineTableOffset: uint32(offset + data.Len()),
+			CmdTableOffset:     uint32(offset + data.Len() + 4*h),
+			Properties:         f.Properties,
+			Width:              int32(width),
+			Height:             int32(h),
+			HotspotX:           int32(f.HotspotX),
+			HotspotY:           int32(f.HotspotY),
+		}
+		infos = append(infos, info)
+
+		var outlines []slp.Outline
+		var offsets []uint32
+		var cmds bytes.Buffer
+		for y, row := range f.Rows {
+			l := left(f, y)
+			outlines = append(outlines, slp.Outline{LeftSpace: uint16(l), RightSpace: uint16(width - l - len(row))})
+			offsets = append(offsets, uint32(int(info.CmdTableOffset)+4*h+cmds.Len()))
+			if f.PlayerColor {
+				cmds.Write([]byte{byte(slp.CMD_PLAYER_COLOR_DRAW), byte(len(row))})
+			} else if len(row) < 64 {
+				cmds.WriteByte(byte(len(row) << 2))
+			} else {
+				cmds.Write([]byte{byte(slp.CMD_GREATER_DRAW) | byte(len(row)>>8)<<4, byte(len(row))})
+			}
+			cmds.Write(row)
+			cmds.WriteByte(byte(slp.CMD_END_OF_ROW))
+		}
+		binary.Write(&data, le, outlines)
+		binary.Write(&data, le, offsets)
+		data.Write(cmds.Bytes())
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, le, hdr)
+	binary.Write(&buf, le, infos)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func left(f Frame, y int) int {
+	if y < len(f.Left) {
+		return f.Left[y]
+	}
+	return 0
+}
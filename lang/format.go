@@ -0,0 +1,231 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	TokenText        TokenKind = iota // plain text
+	TokenPlaceholder                  // printf style placeholder, e.g. %d
+	TokenEscape                       // escape sequence, e.g. \n or %%
+	TokenTag                          // help markup, e.g. <b> or </i>
+	TokenHotkey                       // hotkey marker, e.g. &H
+)
+
+var (
+	ErrPlaceholderMismatch = errors.New("lang: placeholder mismatch")
+	ErrArgCount            = errors.New("lang: wrong number of arguments")
+)
+
+// Token is a part of a language string.
+type Token struct {
+	Kind TokenKind
+	Raw  string // the token as it appears in the string
+
+	// Value depends on the kind: the text, the placeholder without flags
+	// and width (e.g. "%d"), the unescaped character, the tag name
+	// (closing tags start with '/') or the hotkey.
+	Value string
+}
+
+const (
+	// The space flag is not supported: in "10% faster" or "+10% damage" it
+	// would turn "% f" and "% d" into placeholders.
+	placeholderFlags  = "-+#0"
+	placeholderLength = "lh"
+	placeholderVerbs  = "dsuxXcfi"
+)
+
+var escapes = map[byte]string{
+	'n':  "\n",
+	't':  "\t",
+	'r':  "\r",
+	'\\': "\\",
+	'"':  "\"",
+}
+
+// placeholderEnd returns the end of the placeholder
+// %[flags][width][.precision][length]verb starting at s[i] or -1.
+func placeholderEnd(s string, i int) int {
+	j := i + 1
+	skip := func(chars string, limit int) {
+		for n := 0; n < limit && j < len(s) && strings.IndexByte(chars, s[j]) >= 0; n++ {
+			j++
+		}
+	}
+	const digits = "0123456789"
+	skip(placeholderFlags, len(s))
+	skip(digits, len(s))
+	if j < len(s) && s[j] == '.' {
+		j++
+		skip(digits, len(s))
+	}
+	skip(placeholderLength, 2)
+	if j < len(s) && strings.IndexByte(placeholderVerbs, s[j]) >= 0 {
+		return j + 1
+	}
+	return -1
+}
+
+// hotkey returns the hotkey marked by the '&' at s[i] and its size in bytes.
+// Only a letter at the start of a word is a hotkey, so "R&D" has none. The
+// '&' follows a word if text, the start of the pending text, is before it.
+func hotkey(s string, i, text int) (rune, int) {
+	if text < i {
+		if prev, _ := utf8.DecodeLastRuneInString(s[:i]); unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			return 0, 0
+		}
+	}
+	r, size := utf8.DecodeRuneInString(s[i+1:])
+	if r == utf8.RuneError || !unicode.IsLetter(r) {
+		return 0, 0
+	}
+	return r, size
+}
+
+// ParseString splits a language string into tokens.
+func ParseString(s string) []Token {
+	var res []Token
+	text := 0 // start of the pending text
+
+	emit := func(start, end int, kind TokenKind, value string) {
+		if text < start {
+			res = append(res, Token{Kind: TokenText, Raw: s[text:start], Value: s[text:start]})
+		}
+		res = append(res, Token{Kind: kind, Raw: s[start:end], Value: value})
+		text = end
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '%':
+			if i+1 < len(s) && s[i+1] == '%' {
+				emit(i, i+2, TokenEscape, "%")
+				i += 2
+				continue
+			}
+			if end := placeholderEnd(s, i); end >= 0 {
+				emit(i, end, TokenPlaceholder, "%"+s[end-1:end])
+				i = end
+				continue
+			}
+		case '\\':
+			if i+1 < len(s) {
+				if v, found := escapes[s[i+1]]; found {
+					emit(i, i+2, TokenEscape, v)
+					i += 2
+					continue
+				}
+			}
+		case '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 1 && isTagName(s[i+1:i+end]) {
+				emit(i, i+end+1, TokenTag, s[i+1:i+end])
+				i += end + 1
+				continue
+			}
+		case '&':
+			if i+1 < len(s) && s[i+1] == '&' {
+				emit(i, i+2, TokenEscape, "&")
+				i += 2
+				continue
+			}
+			if r, size := hotkey(s, i, text); size > 0 {
+				emit(i, i+1+size, TokenHotkey, string(r))
+				i += 1 + size
+				continue
+			}
+		}
+		i++
+	}
+	if text < len(s) {
+		res = append(res, Token{Kind: TokenText, Raw: s[text:], Value: s[text:]})
+	}
+	return res
+}
+
+func isTagName(name string) bool {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Placeholders returns the placeholders of s in order, e.g. ["%d", "%s"].
+func Placeholders(s string) []string {
+	var res []string
+	for _, tok := range ParseString(s) {
+		if tok.Kind == TokenPlaceholder {
+			res = append(res, tok.Value)
+		}
+	}
+	return res
+}
+
+// CheckPlaceholders returns an error if s does not have the same
+// placeholders as the reference string ref (usually the English one).
+func CheckPlaceholders(ref, s string) error {
+	want, got := Placeholders(ref), Placeholders(s)
+	if len(want) != len(got) {
+		return fmt.Errorf("%w: want %d placeholders, got %d", ErrPlaceholderMismatch, len(want), len(got))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			return fmt.Errorf("%w: placeholder %d: want %s, got %s", ErrPlaceholderMismatch, i+1, want[i], got[i])
+		}
+	}
+	return nil
+}
+
+// Lint checks the placeholders of every string of t that also exists in the
+// reference table and returns the errors by string ID.
+func Lint(ref, t Table) map[uint32]error {
+	res := make(map[uint32]error)
+	for id, s := range t {
+		r, found := ref[id]
+		if !found {
+			continue
+		}
+		if err := CheckPlaceholders(r, s); err != nil {
+			res[id] = err
+		}
+	}
+	return res
+}
+
+// Format renders s for display: placeholders are replaced by the arguments,
+// escape sequences are resolved and markup and hotkey markers are removed
+// (the hotkey itself is kept).
+func Format(s string, args ...any) (string, error) {
+	var sb strings.Builder
+	n := 0
+	for _, tok := range ParseString(s) {
+		switch tok.Kind {
+		case TokenText, TokenEscape, TokenHotkey:
+			sb.WriteString(tok.Value)
+		case TokenPlaceholder:
+			if n >= len(args) {
+				return "", fmt.Errorf("%w: want more than %d", ErrArgCount, len(args))
+			}
+			// %u is not supported by fmt
+			verb := strings.NewReplacer("u", "d", "l", "", "h", "", "i", "d").Replace(tok.Raw)
+			fmt.Fprintf(&sb, verb, args[n])
+			n++
+		}
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("%w: want %d, got %d", ErrArgCount, n, len(args))
+	}
+	return sb.String(), nil
+}
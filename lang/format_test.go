@@ -0,0 +1,84 @@
+package lang_test
+
+import (
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseString(t *testing.T) {
+	toks := lang.ParseString(`Train <b>Villager<b> (&V)\n%d%% of %-5s`)
+
+	var kinds []lang.TokenKind
+	var values []string
+	for _, tok := range toks {
+		kinds = append(kinds, tok.Kind)
+		values = append(values, tok.Value)
+	}
+	assert.Equal(t, []lang.TokenKind{
+		lang.TokenText, lang.TokenTag, lang.TokenText, lang.TokenTag,
+		lang.TokenText, lang.TokenHotkey, lang.TokenText, lang.TokenEscape,
+		lang.TokenPlaceholder, lang.TokenEscape, lang.TokenText, lang.TokenPlaceholder,
+	}, kinds)
+	assert.Equal(t, []string{
+		"Train ", "b", "Villager", "b", " (", "V", ")", "\n", "%d", "%", " of ", "%s",
+	}, values)
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	assert.NoError(t, lang.CheckPlaceholders("%d of %s", "%d von %s"))
+	assert.ErrorIs(t, lang.CheckPlaceholders("%d of %s", "%s von %d"), lang.ErrPlaceholderMismatch)
+	assert.ErrorIs(t, lang.CheckPlaceholders("%d", "kein"), lang.ErrPlaceholderMismatch)
+
+	errs := lang.Lint(lang.Table{1: "%d", 2: "%s"}, lang.Table{1: "%d!", 2: "", 3: "%x"})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs, uint32(2))
+}
+
+func TestFormat(t *testing.T) {
+	s, err := lang.Format(`<b>Gold:<b> %u\n&Build %-3s|`, 100, "x")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Gold: 100\nBuild x  |", s)
+	}
+	_, err = lang.Format("%d %d", 1)
+	assert.ErrorIs(t, err, lang.ErrArgCount)
+	_, err = lang.Format("%d", 1, 2)
+	assert.ErrorIs(t, err, lang.ErrArgCount)
+}
+
+func TestParseStringPercent(t *testing.T) {
+	for s, want := range map[string][]string{
+		"10% faster":          nil,
+		"+10% damage":         nil,
+		"%5.2f%% of %-08lu":   {"%f", "%u"},
+		"%.d %ld %hhd %-+#x":  {"%d", "%d", "%d", "%x"},
+		"%.-5d %5.5.5d %lllu": nil,
+	} {
+		assert.Equal(t, want, lang.Placeholders(s), s)
+	}
+}
+
+func TestParseStringHotkey(t *testing.T) {
+	for s, want := range map[string]string{
+		"&Build":      "Build",
+		"(&V)illager": "(V)illager",
+		"&Über":       "Über",
+		"R&D":         "R&D",
+		"R&&D":        "R&D",
+		"& more":      "& more",
+		"&1":          "&1",
+		"end&":        "end&",
+	} {
+		got, err := lang.Format(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, got, s)
+		}
+	}
+
+	toks := lang.ParseString("&Über")
+	if assert.Len(t, toks, 2) {
+		assert.Equal(t, lang.Token{Kind: lang.TokenHotkey, Raw: "&Ü", Value: "Ü"}, toks[0])
+	}
+}
@@ -1,8 +1,12 @@
 package lang
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 
 	"github.com/saferwall/pe"
 	"golang.org/x/text/encoding/unicode"
@@ -10,17 +14,28 @@ import (
 
 const (
 	MaxStringsPerLeaf = 16
+
+	maxDLLSize = 1 << 30 // Parse reads the whole DLL into memory
+)
+
+var (
+	ErrInvalidEntry = errors.New("lang: invalid entry")
 )
 
 type (
+	// Entry is a string table block of up to 16 strings.
 	Entry struct {
-		ID      uint32
-		Strings []string
+		ID       uint32 // the block ID, see ParseID
+		Language uint32 // the Windows language ID (LANGID), e.g. 0x409 for en-US
+		CodePage uint32 // the code page stored with the resource
+		Strings  []string
 	}
 )
 
 // https://github.com/sandsmark/pcrio/blob/master/pcrio.c
 
+// ParseID returns the block (see Entry.ID) and the offset within the block
+// of a string ID. See StringID for the inverse.
 func ParseID(id uint32) (dir, offset int) {
 	dir = int(id/MaxStringsPerLeaf) + 1
 	offset = int(id % MaxStringsPerLeaf)
@@ -36,22 +51,21 @@ func DecodeUTF16(data []byte) (string, error) {
 func readStrings(buf []byte) ([]string, error) {
 	var res []string
 	// read strings
-	for len(buf) > 2 {
+	for len(buf) >= 2 {
 		// get length
 		l := binary.LittleEndian.Uint16(buf[:2]) // 2 byte length
 		buf = buf[2:]
 		// data
-		n := l * 2
+		n := int(l) * 2
+		if n > len(buf) {
+			return nil, fmt.Errorf("%w: string length %d exceeds block", ErrInvalidEntry, l)
+		}
 		if n == 0 {
 			// empty
 			res = append(res, "")
 		} else {
 			data := buf[:n]
-			if int(n) >= len(buf) { // TODO: ok?
-				buf = nil
-			} else {
-				buf = buf[n:]
-			}
+			buf = buf[n:]
 			str, err := DecodeUTF16(data)
 			if err != nil {
 				return nil, fmt.Errorf("failed to read string: %w", err)
@@ -62,23 +76,11 @@ func readStrings(buf []byte) ([]string, error) {
 	return res, nil
 }
 
-// isLeaf returns true if the hight bit of address is 0 (a leaf)
-func isLeaf(entry pe.ResourceDirectoryEntry) bool {
-	return entry.Struct.OffsetToData&(uint32(1)<<31) == 0
-}
-
 func readEntry(file *pe.File, entry *pe.ResourceDataEntry) ([]string, error) {
-	sec := file.Sections[pe.ImageDirectoryEntryResource]
-
-	pos := entry.Struct.OffsetToData
-	size := entry.Struct.Size
-	codePage := entry.Struct.CodePage
-
-	if codePage != 0x4e4 {
-		panic(fmt.Errorf("invalid codepage %#x", codePage))
+	data, err := file.GetData(entry.Struct.OffsetToData, entry.Struct.Size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEntry, err)
 	}
-
-	data := sec.Data(pos, size, file)
 	return readStrings(data)
 }
 
@@ -88,25 +90,68 @@ func Open(filename string) ([]*Entry, error) {
 		return nil, err
 	}
 	defer fh.Close()
+	return parse(fh)
+}
+
+// Parse reads the string tables of a language DLL from r.
+func Parse(r io.ReaderAt, size int64) ([]*Entry, error) {
+	if size < 0 || size > maxDLLSize {
+		return nil, fmt.Errorf("lang: invalid DLL size %d", size)
+	}
+	data := make([]byte, size)
+	if n, err := r.ReadAt(data, 0); int64(n) < size {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("lang: failed to read DLL: %w", err)
+	}
+	fh, err := pe.NewBytes(data, &pe.Options{})
+	if err != nil {
+		return nil, err
+	}
+	// don't Close fh: it would try to unmap data, which is not mapped
+	return parse(fh)
+}
+
+// OpenFS reads the string tables of the language DLL name in fsys.
+func OpenFS(fsys fs.FS, name string) ([]*Entry, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(data), int64(len(data)))
+}
 
-	if err = fh.Parse(); err != nil {
+// parse reads all string tables of a PE file. A string table may exist in
+// several languages, every language results in an entry of its own.
+func parse(fh *pe.File) ([]*Entry, error) {
+	if err := fh.Parse(); err != nil {
 		return nil, err
 	}
 	var entries []*Entry
-	for _, entry := range fh.Resources.Entries {
-		for _, child := range entry.Directory.Entries {
-			dir := child.Directory
-			if len(dir.Entries) != 1 {
-				panic("invalid entry")
-			}
-			st, err := readEntry(fh, &dir.Entries[0].Data)
-			if err != nil {
-				return nil, err
+	for _, typ := range fh.Resources.Entries {
+		if typ.ID != uint32(pe.RTString) || !typ.IsResourceDir {
+			continue
+		}
+		for _, block := range typ.Directory.Entries {
+			if !block.IsResourceDir {
+				return nil, fmt.Errorf("%w: block %d is not a directory", ErrInvalidEntry, block.ID)
 			}
-			e := &Entry{ID: child.ID,
-				Strings: st,
+			for _, lang := range block.Directory.Entries {
+				if lang.IsResourceDir {
+					return nil, fmt.Errorf("%w: block %d, language %#x is a directory", ErrInvalidEntry, block.ID, lang.ID)
+				}
+				st, err := readEntry(fh, &lang.Data)
+				if err != nil {
+					return nil, fmt.Errorf("block %d, language %#x: %w", block.ID, lang.ID, err)
+				}
+				entries = append(entries, &Entry{
+					ID:       block.ID,
+					Language: lang.ID,
+					CodePage: lang.Data.Struct.CodePage,
+					Strings:  st,
+				})
 			}
-			entries = append(entries, e)
 		}
 	}
 	return entries, nil
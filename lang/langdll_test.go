@@ -0,0 +1,111 @@
+package lang_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/strings.dll is a resource DLL written by lang.WriteDLL.
+var fixtureStrings = lang.Table{
+	1:    "Hello",
+	2:    "%d gold",
+	15:   "last",
+	5000: "Militia",
+	5001: "Miliz",
+}
+
+func newTable(t *testing.T, entries []*lang.Entry) lang.Table {
+	t.Helper()
+	table, err := lang.NewTable(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestOpen(t *testing.T) {
+	entries, err := lang.Open("./testdata/strings.dll")
+	if assert.NoError(t, err) {
+		assert.Equal(t, fixtureStrings, newTable(t, entries))
+	}
+}
+
+func TestParse(t *testing.T) {
+	data, err := os.ReadFile("./testdata/strings.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := lang.Parse(bytes.NewReader(data), int64(len(data)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, fixtureStrings, newTable(t, entries))
+		assert.Len(t, entries, 2)
+		assert.Equal(t, uint32(1), entries[0].ID)
+		assert.Equal(t, uint32(5000/lang.MaxStringsPerLeaf+1), entries[1].ID)
+	}
+
+	_, err = lang.Parse(bytes.NewReader(data[:100]), 100)
+	assert.Error(t, err)
+	_, err = lang.Parse(bytes.NewReader(data), -1)
+	assert.Error(t, err)
+	// the size is larger than the data
+	_, err = lang.Parse(bytes.NewReader(data), int64(len(data))+1)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestParseCompiled(t *testing.T) {
+	data, err := os.ReadFile("./testdata/multi.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := lang.Parse(bytes.NewReader(data), int64(len(data)))
+	if assert.NoError(t, err) {
+		assert.Len(t, entries, 4)
+	}
+
+	fsEntries, err := lang.OpenFS(os.DirFS("testdata"), "multi.dll")
+	if assert.NoError(t, err) {
+		assert.Equal(t, entries, fsEntries)
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	entries, err := lang.OpenFS(os.DirFS("testdata"), "strings.dll")
+	if assert.NoError(t, err) {
+		assert.Equal(t, fixtureStrings, newTable(t, entries))
+	}
+
+	_, err = lang.OpenFS(os.DirFS("testdata"), "missing.dll")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// testdata/multi.dll contains the resources of testdata/multi.rc
+// (Windows-1252): string tables in English and German and an RT_RCDATA
+// resource. They were compiled by llvm-rc and llvm-cvtres and linked by the
+// Go linker, the .rsrc section was then copied into a minimal DLL.
+func TestParseLanguages(t *testing.T) {
+	entries, err := lang.Open("./testdata/multi.dll")
+	if !assert.NoError(t, err) {
+		return
+	}
+	type block struct {
+		ID, Language uint32
+	}
+	var blocks []block
+	for _, e := range entries {
+		blocks = append(blocks, block{e.ID, e.Language})
+		assert.Len(t, e.Strings, lang.MaxStringsPerLeaf)
+	}
+	// the RT_RCDATA resource is skipped
+	assert.Equal(t, []block{{1, 0x407}, {1, 0x409}, {313, 0x407}, {313, 0x409}}, blocks)
+	assert.Equal(t, "Hallo", entries[0].Strings[1])
+	assert.Equal(t, "%d gold", entries[1].Strings[2])
+	assert.Equal(t, "Langschwertkämpfer", entries[2].Strings[9])
+	assert.Equal(t, "Militia", entries[3].Strings[8])
+	assert.Equal(t, "", entries[3].Strings[9])
+}
@@ -0,0 +1,130 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DLLNames are the language DLLs of the game, in the order they are looked up.
+var DLLNames = []string{
+	"language_x1_p1.dll", // The Forgotten (HD)
+	"language_x1.dll",    // The Conquerors
+	"language.dll",       // The Age of Kings
+}
+
+type (
+	// Resolver resolves string IDs.
+	Resolver interface {
+		Get(id uint32) (string, bool)
+	}
+
+	// Table maps string IDs to strings.
+	Table map[uint32]string
+
+	// Layers looks up strings in several resolvers, the first one
+	// containing the string wins. This is how the game resolves strings
+	// of its language DLLs.
+	Layers []Resolver
+)
+
+var (
+	ErrInvalidID = errors.New("lang: invalid string ID")
+)
+
+var (
+	_ Resolver = Table(nil)
+	_ Resolver = Layers(nil)
+)
+
+// StringID returns the string ID for the offset in the given block.
+// It is the inverse of ParseID. Blocks start at 1.
+func StringID(block uint32, offset int) (uint32, error) {
+	if block == 0 || block-1 > math.MaxUint32/MaxStringsPerLeaf || offset < 0 || offset >= MaxStringsPerLeaf {
+		return 0, fmt.Errorf("%w: block %d, offset %d", ErrInvalidID, block, offset)
+	}
+	return (block-1)*MaxStringsPerLeaf + uint32(offset), nil
+}
+
+// StringID returns the string ID of the i-th string of the block.
+func (e *Entry) StringID(i int) (uint32, error) {
+	return StringID(e.ID, i)
+}
+
+// NewTable flattens string table blocks. Empty strings are omitted, the game
+// treats them as missing. If a string exists in several languages, the first
+// one is used.
+func NewTable(entries []*Entry) (Table, error) {
+	t := make(Table)
+	for _, e := range entries {
+		for i, s := range e.Strings {
+			if s == "" {
+				continue
+			}
+			id, err := e.StringID(i)
+			if err != nil {
+				return nil, err
+			}
+			if _, found := t[id]; !found {
+				t[id] = s
+			}
+		}
+	}
+	return t, nil
+}
+
+// OpenTable reads the strings of a language DLL.
+func OpenTable(filename string) (Table, error) {
+	entries, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(entries)
+}
+
+func (t Table) Get(id uint32) (string, bool) {
+	s, found := t[id]
+	return s, found
+}
+
+// IDs returns the sorted string IDs.
+func (t Table) IDs() []uint32 {
+	ids := make([]uint32, 0, len(t))
+	for id := range t {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (l Layers) Get(id uint32) (string, bool) {
+	for _, r := range l {
+		if s, found := r.Get(id); found {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// OpenLayers opens the language DLLs (see DLLNames) in the game directory
+// dir. Missing DLLs are skipped.
+func OpenLayers(dir string) (Layers, error) {
+	var res Layers
+	for _, name := range DLLNames {
+		t, err := OpenTable(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	if len(res) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: os.ErrNotExist}
+	}
+	return res, nil
+}
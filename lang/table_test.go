@@ -0,0 +1,104 @@
+package lang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringID(t *testing.T) {
+	for _, id := range []uint32{0, 1, 15, 16, 5000, 0xffffffff} {
+		block, offset := lang.ParseID(id)
+		res, err := lang.StringID(uint32(block), offset)
+		if assert.NoError(t, err) {
+			assert.Equal(t, id, res)
+		}
+	}
+
+	for _, tc := range []struct {
+		block  uint32
+		offset int
+	}{
+		{0, 0},
+		{0, 15},
+		{1, -1},
+		{1, lang.MaxStringsPerLeaf},
+		{0x10000001, 0},
+	} {
+		_, err := lang.StringID(tc.block, tc.offset)
+		assert.ErrorIs(t, err, lang.ErrInvalidID, "block %d, offset %d", tc.block, tc.offset)
+	}
+}
+
+func TestNewTable(t *testing.T) {
+	entries, err := lang.Open("./testdata/multi.dll")
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the German strings come first
+	assert.Equal(t, lang.Table{
+		1:    "Hallo",
+		2:    "%d gold",
+		15:   "last",
+		5000: "Miliz",
+		5001: "Langschwertkämpfer",
+	}, newTable(t, entries))
+
+	_, err = lang.NewTable([]*lang.Entry{{ID: 0, Strings: []string{"invalid"}}})
+	assert.ErrorIs(t, err, lang.ErrInvalidID)
+	// empty strings are never looked up
+	_, err = lang.NewTable([]*lang.Entry{{ID: 0, Strings: []string{""}}})
+	assert.NoError(t, err)
+}
+
+func TestLayers(t *testing.T) {
+	layers := lang.Layers{
+		lang.Table{1: "patch", 3: "patch only"},
+		lang.Table{1: "expansion", 2: "expansion only"},
+		lang.Table{1: "base", 2: "base", 4: "base only"},
+	}
+	for id, want := range map[uint32]string{
+		1: "patch",
+		2: "expansion only",
+		3: "patch only",
+		4: "base only",
+	} {
+		s, found := layers.Get(id)
+		assert.True(t, found, "%d", id)
+		assert.Equal(t, want, s, "%d", id)
+	}
+	_, found := layers.Get(5)
+	assert.False(t, found)
+	_, found = lang.Layers(nil).Get(1)
+	assert.False(t, found)
+}
+
+func TestOpenLayers(t *testing.T) {
+	dir := t.TempDir()
+	for name, table := range map[string]lang.Table{
+		"language_x1_p1.dll": {1: "patch"},
+		"language.dll":       {1: "base", 2: "base only"},
+	} {
+		if err := lang.CreateDLL(filepath.Join(dir, name), table, lang.LangEnglishUS); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	layers, err := lang.OpenLayers(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// language_x1.dll is missing
+	assert.Len(t, layers, 2)
+	s, _ := layers.Get(1)
+	assert.Equal(t, "patch", s)
+	s, _ = layers.Get(2)
+	assert.Equal(t, "base only", s)
+
+	_, err = lang.OpenLayers(t.TempDir())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
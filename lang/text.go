@@ -0,0 +1,111 @@
+package lang
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLine = errors.New("lang: invalid line")
+)
+
+const utf8BOM = "\ufeff"
+
+// ParseText parses a key-value string file as used by HD and DE
+// (key-value-strings-utf8.txt, strings.txt):
+//
+//	// comment
+//	5000 "Militia"
+//	5001 "Say \"hello\""
+//
+// Within the quotes \" \\ \n and \r are unescaped, any other backslash
+// sequence (e.g. \t) is kept as is. Lines with non-numeric keys are skipped.
+func ParseText(rd io.Reader) (Table, error) {
+	t := make(Table)
+	s := bufio.NewScanner(rd)
+	s.Buffer(nil, 1<<20)
+	for lineNr := 1; s.Scan(); lineNr++ {
+		ln := s.Text()
+		if lineNr == 1 {
+			ln = strings.TrimPrefix(ln, utf8BOM)
+		}
+		ln = strings.TrimSpace(ln)
+		if ln == "" || strings.HasPrefix(ln, "//") {
+			continue
+		}
+
+		key, rest, _ := strings.Cut(ln, " ")
+		if tab := strings.IndexByte(key, '\t'); tab >= 0 {
+			key, rest = ln[:tab], ln[tab+1:]
+		}
+		id, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			continue
+		}
+		str, err := unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %w", ErrInvalidLine, lineNr, err)
+		}
+		t[uint32(id)] = str
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// unquote returns the content of the quoted string at the start of s.
+// Anything after the closing quote must be a comment.
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", errors.New("missing opening quote")
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && unescapes[s[i+1]] != 0:
+			sb.WriteByte(unescapes[s[i+1]])
+			i++
+		case s[i] == '"':
+			rest := strings.TrimSpace(s[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "//") {
+				return "", fmt.Errorf("unexpected %q after string", rest)
+			}
+			return sb.String(), nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", errors.New("missing closing quote")
+}
+
+// unescapes are the characters following a backslash that are unescaped,
+// see textEscaper.
+var unescapes = [256]byte{'"': '"', '\\': '\\', 'n': '\n', 'r': '\r'}
+
+var textEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// OpenText reads a key-value string file, see ParseText.
+func OpenText(filename string) (Table, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return ParseText(fh)
+}
+
+// WriteText writes t as key-value string file, sorted by ID. Quotes,
+// backslashes and line breaks are escaped, see ParseText.
+func WriteText(w io.Writer, t Table) error {
+	bw := bufio.NewWriter(w)
+	for _, id := range t.IDs() {
+		fmt.Fprintf(bw, "%d \"%s\"\n", id, textEscaper.Replace(t[id]))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,43 @@
+package lang_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseText(t *testing.T) {
+	const input = "\ufeff// comment\n" +
+		"5000 \"Militia\"\n" +
+		"\n" +
+		"5001\t\"Say \\\"hello\\\"\\nbye\" // trailing comment\n" +
+		"5002 \"tab\\t back\\\\slash\"\n" +
+		"IDS_NAMED \"skipped\"\n"
+
+	table, err := lang.ParseText(strings.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, lang.Table{
+		5000: "Militia",
+		5001: "Say \"hello\"\nbye",
+		5002: `tab\t back\slash`,
+	}, table)
+
+	table[5003] = `ends with \`
+	table[5004] = "two\r\nlines \\n"
+	var buf bytes.Buffer
+	if assert.NoError(t, lang.WriteText(&buf, table)) {
+		res, err := lang.ParseText(&buf)
+		if assert.NoError(t, err) {
+			assert.Equal(t, table, res)
+		}
+	}
+
+	_, err = lang.ParseText(strings.NewReader("1 \"a\"\n2 \"unterminated\n"))
+	assert.ErrorIs(t, err, lang.ErrInvalidLine)
+}
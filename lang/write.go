@@ -0,0 +1,222 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/saferwall/pe"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	LangEnglishUS = 0x409 // LANGID of en-US
+	CodePageANSI  = 0x4e4 // Windows-1252, used by the original DLLs
+
+	fileAlignment    = 0x200
+	sectionAlignment = 0x1000
+	imageBase        = 0x10000000
+	rsrcRVA          = sectionAlignment // the only section follows the headers
+)
+
+func EncodeUTF16(s string) ([]byte, error) {
+	enc := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder()
+	return enc.Bytes([]byte(s))
+}
+
+// encodeBlock encodes 16 strings of a string table block.
+func encodeBlock(strs *[MaxStringsPerLeaf]string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, s := range strs {
+		data, err := EncodeUTF16(s)
+		if err != nil {
+			return nil, err
+		}
+		n := len(data) / 2
+		if n > 0xffff {
+			return nil, fmt.Errorf("lang: string too long: %d characters", n)
+		}
+		binary.Write(&buf, binary.LittleEndian, uint16(n))
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+func align(n, a int) int {
+	return (n + a - 1) / a * a
+}
+
+// buildResources returns the .rsrc section with one RT_STRING directory.
+//
+//	root directory -> RT_STRING -> block directory -> language -> data entry
+func buildResources(t Table, language, codePage uint32) ([]byte, error) {
+	blocks := make(map[uint32]*[MaxStringsPerLeaf]string)
+	for id, s := range t {
+		dir, offset := ParseID(id)
+		b := blocks[uint32(dir)]
+		if b == nil {
+			b = new([MaxStringsPerLeaf]string)
+			blocks[uint32(dir)] = b
+		}
+		b[offset] = s
+	}
+	ids := make([]uint32, 0, len(blocks))
+	for id := range blocks {
+		ids = append(ids, id)
+	}
+	// entries of a resource directory must be sorted
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	const (
+		dirSize   = 16
+		entrySize = 8
+		dataSize  = 16
+		isDir     = 1 << 31
+	)
+	n := len(ids)
+	typeDirOff := 0
+	blockDirOff := typeDirOff + dirSize + entrySize
+	langDirOff := blockDirOff + dirSize + n*entrySize
+	dataEntryOff := langDirOff + n*(dirSize+entrySize)
+	dataOff := dataEntryOff + n*dataSize
+
+	var dirs, data bytes.Buffer
+	le := binary.LittleEndian
+	writeDir := func(numIDs int, entries ...[2]uint32) {
+		binary.Write(&dirs, le, pe.ImageResourceDirectory{NumberOfIDEntries: uint16(numIDs)})
+		for _, e := range entries {
+			binary.Write(&dirs, le, e)
+		}
+	}
+
+	writeDir(1, [2]uint32{uint32(pe.RTString), isDir | uint32(blockDirOff)})
+
+	blockEntries := make([][2]uint32, n)
+	for i, id := range ids {
+		blockEntries[i] = [2]uint32{id, isDir | uint32(langDirOff+i*(dirSize+entrySize))}
+	}
+	writeDir(n, blockEntries...)
+
+	for i := range ids {
+		writeDir(1, [2]uint32{language, uint32(dataEntryOff + i*dataSize)})
+	}
+
+	for _, id := range ids {
+		block, err := encodeBlock(blocks[id])
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", id, err)
+		}
+		binary.Write(&dirs, le, pe.ImageResourceDataEntry{
+			OffsetToData: uint32(rsrcRVA + dataOff + data.Len()),
+			Size:         uint32(len(block)),
+			CodePage:     codePage,
+		})
+		data.Write(block)
+		data.Write(make([]byte, align(data.Len(), 4)-data.Len()))
+	}
+	if dirs.Len() != dataOff {
+		return nil, fmt.Errorf("resource directories take %d bytes instead of %d", dirs.Len(), dataOff)
+	}
+	return append(dirs.Bytes(), data.Bytes()...), nil
+}
+
+// WriteDLL writes a minimal resource-only PE DLL (32 bit) containing the
+// strings of t as RT_STRING resources in the given language, e.g.
+// LangEnglishUS. The DLL can be used as language_x1_p1.dll, which takes
+// precedence over the other language DLLs of the game.
+//
+// WriteDLL only creates new DLLs, it cannot modify an existing one: other
+// resources, other languages and code of the original DLL would be lost. To
+// change strings of the game, write the changed strings to a new DLL that
+// is looked up first (see DLLNames).
+func WriteDLL(w io.Writer, t Table, language uint32) error {
+	rsrc, err := buildResources(t, language, CodePageANSI)
+	if err != nil {
+		return fmt.Errorf("lang: %w", err)
+	}
+
+	const (
+		peOffset   = 0x40
+		numDataDir = 16
+	)
+	rawSize := align(len(rsrc), fileAlignment)
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+
+	// DOS header: only the magic and the offset of the PE header are used
+	var dos [peOffset]byte
+	copy(dos[:], "MZ")
+	le.PutUint32(dos[0x3c:], peOffset)
+	buf.Write(dos[:])
+
+	buf.WriteString("PE\x00\x00")
+	binary.Write(&buf, le, pe.ImageFileHeader{
+		Machine:              pe.ImageFileMachineI386,
+		NumberOfSections:     1,
+		SizeOfOptionalHeader: uint16(binary.Size(pe.ImageOptionalHeader32{})),
+		Characteristics: pe.ImageFileExecutableImage |
+			pe.ImageFile32BitMachine |
+			pe.ImageFileDLL,
+	})
+
+	opt := pe.ImageOptionalHeader32{
+		Magic:                       pe.ImageNtOptionalHeader32Magic,
+		MajorLinkerVersion:          6,
+		SizeOfInitializedData:       uint32(rawSize),
+		BaseOfCode:                  rsrcRVA,
+		BaseOfData:                  rsrcRVA,
+		ImageBase:                   imageBase,
+		SectionAlignment:            sectionAlignment,
+		FileAlignment:               fileAlignment,
+		MajorOperatingSystemVersion: 4,
+		MajorSubsystemVersion:       4,
+		SizeOfImage:                 uint32(rsrcRVA + align(len(rsrc), sectionAlignment)),
+		SizeOfHeaders:               fileAlignment,
+		Subsystem:                   pe.ImageSubsystemWindowsGUI,
+		SizeOfStackReserve:          0x100000,
+		SizeOfStackCommit:           0x1000,
+		SizeOfHeapReserve:           0x100000,
+		SizeOfHeapCommit:            0x1000,
+		NumberOfRvaAndSizes:         numDataDir,
+	}
+	opt.DataDirectory[pe.ImageDirectoryEntryResource] = pe.DataDirectory{
+		VirtualAddress: rsrcRVA,
+		Size:           uint32(len(rsrc)),
+	}
+	binary.Write(&buf, le, opt)
+
+	sec := pe.ImageSectionHeader{
+		VirtualSize:      uint32(len(rsrc)),
+		VirtualAddress:   rsrcRVA,
+		SizeOfRawData:    uint32(rawSize),
+		PointerToRawData: fileAlignment,
+		Characteristics:  pe.ImageSectionCntInitializedData | pe.ImageSectionMemRead,
+	}
+	copy(sec.Name[:], ".rsrc")
+	binary.Write(&buf, le, sec)
+
+	buf.Write(make([]byte, fileAlignment-buf.Len()))
+	buf.Write(rsrc)
+	buf.Write(make([]byte, rawSize-len(rsrc)))
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// CreateDLL writes t to a new resource DLL, see WriteDLL. An existing file
+// is replaced.
+func CreateDLL(filename string, t Table, language uint32) error {
+	fh, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteDLL(fh, t, language); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
@@ -0,0 +1,36 @@
+package lang_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDLL(t *testing.T) {
+	table := lang.Table{
+		0:     "first",
+		15:    "last of block",
+		16:    "next block",
+		5000:  "Militia",
+		26093: "Ümlaut ✓ 中文",
+	}
+
+	filename := filepath.Join(t.TempDir(), "language_x1_p1.dll")
+	if !assert.NoError(t, lang.CreateDLL(filename, table, lang.LangEnglishUS)) {
+		return
+	}
+
+	entries, err := lang.Open(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, e := range entries {
+		assert.Equal(t, uint32(lang.LangEnglishUS), e.Language)
+		assert.Equal(t, uint32(lang.CodePageANSI), e.CodePage)
+		assert.Len(t, e.Strings, lang.MaxStringsPerLeaf)
+	}
+	assert.Equal(t, table, newTable(t, entries))
+}
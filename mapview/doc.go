@@ -0,0 +1,8 @@
+// Render the terrain of a scenario or recorded game.
+//
+// Minimap draws one pixel per tile with the minimap colors of the terrains.
+// Renderer draws the isometric view with the terrain SLPs, blended with the
+// blendomatic, lit with an ICM and, with filtermaps, sloped. Both take a
+// scenario.Map, which is also the map of a recorded game (see
+// rec.Header.Map).
+package mapview
@@ -0,0 +1,237 @@
+package mapview_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/blendomatic"
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/filtermap"
+	"gopkg.in/KlemensWinter/go-genie.v1/graphics"
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/mapview"
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tileSLP returns an SLP with a single flat tile filled with col.
+func tileSLP(col byte) []byte {
+	f := testutil.Frame{Width: mapview.TileWidth}
+	for y := 0; y < mapview.TileHeight; y++ {
+		half := 2 * min(y, mapview.TileHeight-1-y)
+		f.Left = append(f.Left, mapview.TileWidth/2-half)
+		f.Rows = append(f.Rows, bytes.Repeat([]byte{col}, 2*half+1))
+	}
+	return testutil.SLP(f)
+}
+
+// buildBlendomatic returns a blendomatic with one mode whose tiles all have
+// the given alpha value.
+func buildBlendomatic(t *testing.T, alpha byte) *blendomatic.Blendomatic {
+	t.Helper()
+	le := binary.LittleEndian
+	var buf bytes.Buffer
+	binary.Write(&buf, le, [2]uint32{1, 31})
+	binary.Write(&buf, le, uint32(filtermap.TilePixels))
+	buf.Write(bytes.Repeat([]byte{1}, 31))
+	binary.Write(&buf, le, make([]uint32, filtermap.TilePixels))
+	buf.Write(bytes.Repeat([]byte{alpha}, 31*filtermap.TilePixels))
+
+	bm, err := blendomatic.New(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bm
+}
+
+var terrains = []dat.Terrain{
+	{SLP: 100, Colors: [3]uint8{1, 2, 3}, TerrainToDraw: -1, BlendPriority: 1},
+	{SLP: 101, Colors: [3]uint8{4, 5, 6}, TerrainToDraw: -1, BlendPriority: 2},
+}
+
+func newResolver(t *testing.T) *graphics.Resolver {
+	archive := testutil.DRSReader(t, "slp", map[drs.FileID][]byte{
+		100: tileSLP(100),
+		101: tileSLP(20),
+	})
+	f := &dat.File{TerrainBlock: dat.TerrainBlock{Terrains: terrains}}
+	return graphics.NewResolver(f, palette.NewSet(), archive)
+}
+
+func pal(i int) color.RGBA {
+	return color.RGBAModel.Convert(palette.Default[i]).(color.RGBA)
+}
+
+func TestMinimap(t *testing.T) {
+	m := scenario.NewMap(4, 3, 0)
+	m.Fill(image.Rect(2, 0, 4, 3), 1)
+	m.Tile(1, 1).Elevation = 1
+
+	img, err := mapview.Minimap(&m, terrains, palette.Default)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, image.Rect(0, 0, 4, 3), img.Bounds())
+	assert.Equal(t, uint8(2), img.ColorIndexAt(0, 2))
+	assert.Equal(t, uint8(5), img.ColorIndexAt(3, 0))
+	// the light comes from the top left
+	assert.Equal(t, uint8(6), img.ColorIndexAt(2, 2))
+	assert.Equal(t, uint8(1), img.ColorIndexAt(0, 0))
+	assert.Equal(t, uint8(2), img.ColorIndexAt(1, 1))
+
+	m.Tile(0, 0).Terrain = 7
+	_, err = mapview.Minimap(&m, terrains, palette.Default)
+	assert.ErrorIs(t, err, mapview.ErrInvalidTerrain)
+}
+
+func TestRender(t *testing.T) {
+	m := scenario.NewMap(2, 2, 0)
+	m.Tile(1, 0).Terrain = 1
+
+	r := mapview.NewRenderer(newResolver(t), nil, nil, palette.Default)
+	img, err := r.Render(&m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, image.Rect(0, 0, 193, 97), img.Bounds())
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(0, 0))
+	assert.Equal(t, pal(100), img.RGBAAt(96, 24))  // tile 0, 1
+	assert.Equal(t, pal(100), img.RGBAAt(48, 48))  // tile 0, 0
+	assert.Equal(t, pal(100), img.RGBAAt(144, 48)) // tile 1, 1
+	assert.Equal(t, pal(20), img.RGBAAt(96, 72))   // tile 1, 0
+
+	m.Tile(0, 0).Terrain = 7
+	_, err = r.Render(&m)
+	assert.ErrorIs(t, err, mapview.ErrInvalidTerrain)
+}
+
+func TestRenderElevation(t *testing.T) {
+	m := scenario.NewMap(2, 2, 0)
+	m.Tile(0, 0).Elevation = 1
+
+	r := mapview.NewRenderer(newResolver(t), nil, nil, palette.Default)
+	img, err := r.Render(&m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, image.Rect(0, 0, 193, 97+mapview.ElevationHeight), img.Bounds())
+	// the top corner of tile 0, 0 is raised, the one of tile 0, 1 is not
+	assert.Equal(t, pal(100), img.RGBAAt(48, 26))
+	assert.Equal(t, pal(100), img.RGBAAt(96, 26))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(96, 22))
+	// the column of tile 0, 0
+	assert.Equal(t, pal(100), img.RGBAAt(48, 72))
+}
+
+func TestRenderBlend(t *testing.T) {
+	m := scenario.NewMap(2, 1, 0)
+	m.Tile(1, 0).Terrain = 1
+	center := image.Pt(48, 24) // of tile 0, 0
+
+	r := mapview.NewRenderer(newResolver(t), buildBlendomatic(t, 0), nil, palette.Default)
+	img, err := r.Render(&m)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(20), img.RGBAAt(center.X, center.Y))
+	}
+
+	r = mapview.NewRenderer(newResolver(t), buildBlendomatic(t, 128), nil, palette.Default)
+	img, err = r.Render(&m)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal(100), img.RGBAAt(center.X, center.Y))
+	}
+}
+
+func TestRenderLighting(t *testing.T) {
+	m := scenario.NewMap(3, 3, 0)
+	m.Tile(2, 2).Elevation = 2
+	m.Tile(1, 1).Elevation = 1
+	lightmap, err := icm.Generate(palette.Default, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := mapview.NewRenderer(newResolver(t), nil, &lightmap, palette.Default)
+	img, err := r.Render(&m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	top := 2 * mapview.ElevationHeight
+	flatColor := palette.Default[lightmap[r.Lighting.Flat].Index(pal(100))]
+	litColor := palette.Default[lightmap[r.Lighting.Lit].Index(pal(100))]
+	assert.NotEqual(t, flatColor, litColor)
+	// tile 1, 0 is flat, tile 1, 1 rises to the bottom right
+	assert.Equal(t, color.RGBAModel.Convert(flatColor), img.RGBAAt(96, 96+top))
+	assert.Equal(t, color.RGBAModel.Convert(litColor), img.RGBAAt(144, 72+top-mapview.ElevationHeight))
+
+	r.Lighting.Flat = icm.NumRows
+	_, err = r.Render(&m)
+	assert.ErrorIs(t, err, icm.ErrInvalidBrightness)
+}
+
+// slopeFiltermap returns a filtermap of a tile one level higher than a flat
+// tile, every pixel is the top pixel of the flat tile.
+func slopeFiltermap() filtermap.Filtermap {
+	var fm filtermap.Filtermap
+	for y := 0; y < mapview.TileHeight+mapview.ElevationHeight; y++ {
+		ln := filtermap.Line{Pixels: make([]filtermap.Pixel, mapview.TileWidth)}
+		for i := range ln.Pixels {
+			ln.Pixels[i].Sources = []filtermap.Source{{Index: 0, Weight: 1}}
+		}
+		fm.Lines = append(fm.Lines, ln)
+	}
+	return fm
+}
+
+func TestRenderSlopes(t *testing.T) {
+	m := scenario.NewMap(2, 1, 0)
+	m.Tile(1, 0).Elevation = 1
+	lightmap, err := icm.Generate(palette.Default, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// tile 0, 0 touches tile 1, 0 with its right and bottom corner, the
+	// other slopes have no pixels
+	const slope = 7
+	fm := slopeFiltermap()
+	// the left pixel of the bottom line is not covered
+	bottom := len(fm.Lines) - 1
+	fm.Lines[bottom].Pixels[0].Sources = []filtermap.Source{{Index: 0, Weight: 0}}
+	r := mapview.NewRenderer(newResolver(t), nil, &lightmap, palette.Default)
+	r.Filtermaps = make([]filtermap.Filtermap, filtermap.NumSlopes)
+	_, err = r.Render(&m)
+	assert.ErrorIs(t, err, mapview.ErrNoSlopes)
+
+	r.Slopes = make([]int, mapview.CornerBottom<<1)
+	r.Slopes[mapview.CornerRight|mapview.CornerBottom] = slope
+	r.Filtermaps[slope] = fm
+	r.Lightmaps = make([]filtermap.Lightmap, filtermap.NumSlopes)
+	r.Lightmaps[slope] = bytes.Repeat([]byte{r.Lighting.Lit}, fm.NumPixels())
+
+	img, err := r.Render(&m)
+	if !assert.NoError(t, err) {
+		return
+	}
+	top := mapview.ElevationHeight
+	litColor := color.RGBAModel.Convert(palette.Default[lightmap[r.Lighting.Lit].Index(pal(100))])
+	flatColor := color.RGBAModel.Convert(palette.Default[lightmap[r.Lighting.Flat].Index(pal(100))])
+	// the slope covers the rectangle of the filtermap at the flat tile
+	assert.Equal(t, litColor, img.RGBAAt(0, top))
+	assert.Equal(t, litColor, img.RGBAAt(48, top+60))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(0, top+bottom))
+	assert.Equal(t, litColor, img.RGBAAt(1, top+bottom))
+	// tile 1, 0 is flat and drawn one level higher, without a column
+	assert.Equal(t, flatColor, img.RGBAAt(96, 72))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(110, 80))
+
+	r.Filtermaps = r.Filtermaps[:slope]
+	_, err = r.Render(&m)
+	assert.Error(t, err)
+}
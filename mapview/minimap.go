@@ -0,0 +1,75 @@
+package mapview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+)
+
+// Shading of a tile, see shade.
+const (
+	shaded = -1
+	flat   = 0
+	lit    = 1
+)
+
+var (
+	ErrInvalidTerrain = errors.New("mapview: invalid terrain")
+)
+
+// elevation returns the elevation of the tile, tiles outside of the map have
+// the elevation of the nearest tile.
+func elevation(m *scenario.Map, x, y int) int {
+	if len(m.Rows) == 0 {
+		return 0
+	}
+	row := m.Rows[max(0, min(y, len(m.Rows)-1))].Tiles
+	if len(row) == 0 {
+		return 0
+	}
+	return int(row[max(0, min(x, len(row)-1))].Elevation)
+}
+
+// shade returns whether the tile is a slope facing the light, which comes
+// from the left corner of the map, or facing away from it.
+func shade(m *scenario.Map, x, y int) int {
+	d := elevation(m, x+1, y+1) - elevation(m, x-1, y-1)
+	switch {
+	case d > 0:
+		return lit
+	case d < 0:
+		return shaded
+	}
+	return flat
+}
+
+// terrain returns the terrain of the tile.
+func terrain(terrains []dat.Terrain, m *scenario.Map, x, y int) (*dat.Terrain, error) {
+	id := int(m.Rows[y].Tiles[x].Terrain)
+	if id >= len(terrains) {
+		return nil, fmt.Errorf("%w: %d at %d, %d", ErrInvalidTerrain, id, x, y)
+	}
+	return &terrains[id], nil
+}
+
+// Minimap draws the map with one pixel per tile in the minimap colors of the
+// terrains, x to the right and y downwards. The colors are indices of pal:
+// Colors[1] for flat tiles, Colors[0] for slopes facing the light and
+// Colors[2] for slopes facing away from it.
+func Minimap(m *scenario.Map, terrains []dat.Terrain, pal color.Palette) (*image.Paletted, error) {
+	img := image.NewPaletted(image.Rect(0, 0, int(m.Width), len(m.Rows)), pal)
+	for y, row := range m.Rows {
+		for x := range row.Tiles {
+			t, err := terrain(terrains, m, x, y)
+			if err != nil {
+				return nil, err
+			}
+			img.SetColorIndex(x, y, t.Colors[1-shade(m, x, y)])
+		}
+	}
+	return img, nil
+}
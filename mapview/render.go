@@ -0,0 +1,449 @@
+package mapview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/blendomatic"
+	"gopkg.in/KlemensWinter/go-genie.v1/dat"
+	"gopkg.in/KlemensWinter/go-genie.v1/filtermap"
+	"gopkg.in/KlemensWinter/go-genie.v1/graphics"
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+	"gopkg.in/KlemensWinter/go-genie.v1/slp"
+)
+
+const (
+	TileWidth       = filtermap.TileWidth
+	TileHeight      = filtermap.TileHeight
+	ElevationHeight = 24 // pixels per elevation level
+
+	maxAlpha = 128 // of the blendomatic
+)
+
+var (
+	ErrInvalidBlendMode = errors.New("mapview: invalid blending mode")
+	ErrNoSlopes         = errors.New("mapview: filtermaps without slopes")
+)
+
+type (
+	// Lighting contains the ICM rows tiles are drawn with.
+	Lighting struct {
+		Shaded uint8 // slopes facing away from the light and the sides of elevated tiles
+		Flat   uint8
+		Lit    uint8 // slopes facing the light
+	}
+
+	// Renderer renders the isometric view of a map. Tile (0, 0) is the
+	// left corner of the map, x grows to the bottom right and y to the top
+	// right. Each elevation level raises a tile by ElevationHeight pixels.
+	Renderer struct {
+		// Graphics provides the terrains, the terrain SLPs and their
+		// palettes.
+		Graphics *graphics.Resolver
+
+		Blendomatic *blendomatic.Blendomatic // optional; without it terrains are not blended
+		ICM         *icm.Map                 // optional; without it tiles are not lit
+		Palette     color.Palette            // the palette of the ICM
+		Lighting    Lighting
+
+		// Filtermaps and Lightmaps render sloped tiles (see package
+		// filtermap), they need ICM. Without filtermaps elevated tiles
+		// are drawn as columns.
+		Filtermaps []filtermap.Filtermap
+		Lightmaps  []filtermap.Lightmap
+		// Slopes maps the raised corners of a tile, a combination of the
+		// Corner constants, to its filtermap. The order of filtermaps.dat
+		// is not documented, so Slopes is required with Filtermaps.
+		Slopes []int
+
+		textures map[texture]*image.RGBA
+	}
+
+	// texture is a frame of a terrain SLP.
+	texture struct {
+		terrain int
+		frame   int
+	}
+
+	// overlay is a neighboring terrain blended over a tile.
+	overlay struct {
+		terrain int
+		sides   int // bits of the neighbors table
+		corners int
+	}
+)
+
+// Corners of a tile, the raised corners of a sloped tile are combined.
+const (
+	CornerLeft = 1 << iota
+	CornerTop
+	CornerRight
+	CornerBottom
+
+	allCorners = CornerLeft | CornerTop | CornerRight | CornerBottom
+)
+
+// DefaultLighting matches the rows of icm.DefaultGenerateOptions.
+var DefaultLighting = Lighting{Shaded: 3, Flat: 0, Lit: 7}
+
+// cornerTiles are the tiles sharing a corner with the tile at 0, 0.
+var cornerTiles = [4][3]image.Point{
+	{{-1, 0}, {0, -1}, {-1, -1}}, // left
+	{{-1, 0}, {0, 1}, {-1, 1}},   // top
+	{{1, 0}, {0, 1}, {1, 1}},     // right
+	{{1, 0}, {0, -1}, {1, -1}},   // bottom
+}
+
+// neighbors are the sides and corners of a tile.
+var neighbors = []struct {
+	dx, dy       int
+	side, corner int
+}{
+	{0, 1, 1, 0},   // upper right
+	{1, 0, 2, 0},   // lower right
+	{0, -1, 4, 0},  // lower left
+	{-1, 0, 8, 0},  // upper left
+	{1, 1, 0, 1},   // right
+	{1, -1, 0, 2},  // bottom
+	{-1, -1, 0, 4}, // left
+	{-1, 1, 0, 8},  // top
+}
+
+var (
+	sideMasks     = [4]int{12, 4, 8, 0} // upper right, lower right, lower left, upper left
+	cornerMasks   = [4]int{16, 17, 18, 19}
+	cornerSides   = [4]int{1 | 2, 2 | 4, 4 | 8, 8 | 1} // the sides touching the corners
+	combinedMasks = map[int]int{0x0a: 20, 0x05: 21, 0x07: 22, 0x0b: 23, 0x0d: 24, 0x0e: 25, 0x0f: 26}
+
+	flatPixels = filtermap.FlatPixels()
+)
+
+func NewRenderer(g *graphics.Resolver, bm *blendomatic.Blendomatic, m *icm.Map, pal color.Palette) *Renderer {
+	return &Renderer{
+		Graphics:    g,
+		Blendomatic: bm,
+		ICM:         m,
+		Palette:     pal,
+		Lighting:    DefaultLighting,
+		textures:    make(map[texture]*image.RGBA),
+	}
+}
+
+// corners returns the corners of the tile touching a higher tile.
+func corners(m *scenario.Map, x, y int) int {
+	elev := elevation(m, x, y)
+	res := 0
+	for i, tiles := range cornerTiles {
+		for _, t := range tiles {
+			if elevation(m, x+t.X, y+t.Y) > elev {
+				res |= 1 << i
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (r *Renderer) terrains() []dat.Terrain {
+	return r.Graphics.Data.TerrainBlock.Terrains
+}
+
+// Render draws the map. The image is (w+h)*48+1 pixels wide and
+// (w+h)*24+1 pixels high, plus the height of the highest elevation. It
+// returns ErrNoSlopes if Filtermaps are set without Slopes.
+func (r *Renderer) Render(m *scenario.Map) (*image.RGBA, error) {
+	if len(r.Filtermaps) > 0 && r.Slopes == nil {
+		return nil, ErrNoSlopes
+	}
+	w, h := int(m.Width), len(m.Rows)
+	elev := 0
+	for _, row := range m.Rows {
+		for _, t := range row.Tiles {
+			elev = max(elev, int(t.Elevation))
+		}
+	}
+	top := elev * ElevationHeight
+	img := image.NewRGBA(image.Rect(0, 0, (w+h)*(TileWidth/2)+1, (w+h)*(TileHeight/2)+1+top))
+
+	// back to front, one diagonal after another
+	for d := 1 - h; d < w; d++ {
+		for y := max(0, -d); y < h; y++ {
+			x := d + y
+			if x >= len(m.Rows[y].Tiles) {
+				break
+			}
+			if err := r.drawTile(img, m, x, y, top); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return img, nil
+}
+
+// drawTile draws the tile and its column down to the lowest tile in front of
+// it.
+func (r *Renderer) drawTile(dst *image.RGBA, m *scenario.Map, x, y, top int) error {
+	tile, err := r.tile(m, x, y)
+	if err != nil {
+		return err
+	}
+	pos := image.Pt((x+y)*(TileWidth/2), (x-y+len(m.Rows)-1)*(TileHeight/2)+top)
+	elev := int(m.Rows[y].Tiles[x].Elevation)
+	if len(r.Filtermaps) > 0 {
+		return r.drawSlope(dst, tile, corners(m, x, y), pos.Sub(image.Pt(0, elev*ElevationHeight)))
+	}
+
+	low := min(elev, elevation(m, x+1, y), elevation(m, x, y-1), elevation(m, x+1, y-1))
+	if low < elev {
+		side := clone(tile)
+		if err := r.light(side, r.Lighting.Shaded); err != nil {
+			return err
+		}
+		for level := low; level < elev; level++ {
+			p := pos.Sub(image.Pt(0, level*ElevationHeight))
+			draw.Draw(dst, side.Bounds().Add(p), side, image.Point{}, draw.Over)
+		}
+	}
+
+	rows := [3]uint8{r.Lighting.Shaded, r.Lighting.Flat, r.Lighting.Lit}
+	if err := r.light(tile, rows[shade(m, x, y)+1]); err != nil {
+		return err
+	}
+	p := pos.Sub(image.Pt(0, elev*ElevationHeight))
+	draw.Draw(dst, tile.Bounds().Add(p), tile, image.Point{}, draw.Over)
+	return nil
+}
+
+// drawSlope draws the tile with the filtermap of its raised corners, p is
+// the position of the flat tile. A tile whose corners are all raised is
+// drawn flat one level higher.
+func (r *Renderer) drawSlope(dst, tile *image.RGBA, corners int, p image.Point) error {
+	if corners == allCorners {
+		corners = 0
+		p.Y -= ElevationHeight
+	}
+	if corners == 0 {
+		if err := r.light(tile, r.Lighting.Flat); err != nil {
+			return err
+		}
+		draw.Draw(dst, tile.Bounds().Add(p), tile, image.Point{}, draw.Over)
+		return nil
+	}
+
+	if corners >= len(r.Slopes) {
+		return fmt.Errorf("mapview: no slope for corners %#x", corners)
+	}
+	fr := filtermap.Renderer{Filtermaps: r.Filtermaps, Lightmaps: r.Lightmaps, ICM: r.ICM, Palette: r.Palette}
+	slope := r.Slopes[corners]
+	img, err := fr.Render(slope, tile)
+	if err != nil {
+		return err
+	}
+	// the top of the filtermap is the highest corner
+	if corners&CornerTop != 0 {
+		p.Y -= ElevationHeight
+	}
+	for y, ln := range r.Filtermaps[slope].Lines {
+		for i := range ln.Pixels {
+			if ln.Pixels[i].Weight() == 0 {
+				continue
+			}
+			if idx := int(img.ColorIndexAt(ln.X+i, y)); idx < len(r.Palette) {
+				dst.Set(p.X+ln.X+i, p.Y+y, r.Palette[idx])
+			}
+		}
+	}
+	return nil
+}
+
+// tile returns the texture of the tile blended with its neighbors.
+func (r *Renderer) tile(m *scenario.Map, x, y int) (*image.RGBA, error) {
+	if _, err := terrain(r.terrains(), m, x, y); err != nil {
+		return nil, err
+	}
+	base, err := r.texture(int(m.Rows[y].Tiles[x].Terrain), x, y)
+	if err != nil {
+		return nil, err
+	}
+	img := clone(base)
+	if r.Blendomatic != nil {
+		if err := r.blend(img, m, x, y); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// texture returns the frame of the terrain for the tile at x, y. Terrains
+// repeat every TerrainDimensions[1] columns and TerrainDimensions[0] rows.
+func (r *Renderer) texture(id, x, y int) (*image.RGBA, error) {
+	terrains := r.terrains()
+	t := &terrains[id]
+	if d := int(t.TerrainToDraw); d >= 0 && d < len(terrains) {
+		id, t = d, &terrains[d]
+	}
+	rows, cols := max(int(t.TerrainDimensions[0]), 1), max(int(t.TerrainDimensions[1]), 1)
+	key := texture{id, (y%rows)*cols + x%cols}
+	if img, found := r.textures[key]; found {
+		return img, nil
+	}
+
+	rd, err := r.Graphics.SLP(t.SLP)
+	if err != nil {
+		return nil, fmt.Errorf("mapview: terrain %d: %w", id, err)
+	}
+	if len(rd.Frames) == 0 {
+		return nil, fmt.Errorf("%w: terrain %d has no frames", ErrInvalidTerrain, id)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, TileWidth, TileHeight))
+	if err := slp.DrawFrame(img, r.Graphics.Palettes, rd.Frames[key.frame%len(rd.Frames)], 0, 0); err != nil {
+		return nil, fmt.Errorf("mapview: terrain %d: %w", id, err)
+	}
+	if r.textures == nil {
+		r.textures = make(map[texture]*image.RGBA)
+	}
+	r.textures[key] = img
+	return img, nil
+}
+
+// above returns whether terrain a is blended over terrain b.
+func above(terrains []dat.Terrain, a, b int) bool {
+	if a == b || a >= len(terrains) {
+		return false
+	}
+	pa, pb := terrains[a].BlendPriority, terrains[b].BlendPriority
+	return pa > pb || pa == pb && a > b
+}
+
+// blend draws the neighboring terrains with a higher priority over img.
+func (r *Renderer) blend(img *image.RGBA, m *scenario.Map, x, y int) error {
+	terrains := r.terrains()
+	id := int(m.Rows[y].Tiles[x].Terrain)
+	var overlays []*overlay
+	for _, n := range neighbors {
+		t := m.Tile(x+n.dx, y+n.dy)
+		if t == nil || !above(terrains, int(t.Terrain), id) {
+			continue
+		}
+		var o *overlay
+		for _, v := range overlays {
+			if v.terrain == int(t.Terrain) {
+				o = v
+			}
+		}
+		if o == nil {
+			o = &overlay{terrain: int(t.Terrain)}
+			overlays = append(overlays, o)
+		}
+		o.sides |= n.side
+		o.corners |= n.corner
+	}
+	sort.Slice(overlays, func(i, j int) bool {
+		return above(terrains, overlays[j].terrain, overlays[i].terrain)
+	})
+
+	for _, o := range overlays {
+		mode := int(terrains[o.terrain].BlendType)
+		if mode < 0 || mode >= len(r.Blendomatic.Modes) {
+			return fmt.Errorf("%w: %d of terrain %d", ErrInvalidBlendMode, mode, o.terrain)
+		}
+		src, err := r.texture(o.terrain, x, y)
+		if err != nil {
+			return err
+		}
+		for _, mask := range masks(o.sides, o.corners, x+y) {
+			alpha := r.Blendomatic.Modes[mode].TileAlpha
+			if mask >= len(alpha) {
+				return fmt.Errorf("%w: %d has no tile %d", ErrInvalidBlendMode, mode, mask)
+			}
+			applyMask(img, src, alpha[mask])
+		}
+	}
+	return nil
+}
+
+// masks returns the blendomatic tiles for the sides and corners touched by
+// a terrain:
+//
+//	0-3    upper left side (4 variations)
+//	4-7    lower right side
+//	8-11   lower left side
+//	12-15  upper right side
+//	16-19  right, bottom, left and top corner
+//	20     upper left and lower right side
+//	21     upper right and lower left side
+//	22-25  all sides except upper left, lower left, lower right, upper right
+//	26     all sides
+//
+// Other combinations of sides are drawn mask by mask.
+func masks(sides, corners, variation int) []int {
+	var res []int
+	if mask, found := combinedMasks[sides]; found {
+		res = append(res, mask)
+	} else {
+		for i, mask := range sideMasks {
+			if sides&(1<<i) != 0 {
+				res = append(res, mask+variation%4)
+			}
+		}
+	}
+	for i, mask := range cornerMasks {
+		if corners&(1<<i) != 0 && sides&cornerSides[i] == 0 {
+			res = append(res, mask)
+		}
+	}
+	return res
+}
+
+// applyMask blends src over dst with the alpha values of a blendomatic tile.
+// An alpha value of 0 draws src opaque, 128 leaves dst unchanged.
+func applyMask(dst, src *image.RGBA, alpha []uint8) {
+	for i, p := range flatPixels {
+		if i >= len(alpha) {
+			break
+		}
+		o := maxAlpha - int(min(alpha[i], maxAlpha))
+		s, d := src.PixOffset(p.X, p.Y), dst.PixOffset(p.X, p.Y)
+		if o == 0 || src.Pix[s+3] == 0 {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			dst.Pix[d+k] = uint8((int(dst.Pix[d+k])*(maxAlpha-o) + int(src.Pix[s+k])*o) / maxAlpha)
+		}
+		dst.Pix[d+3] = max(dst.Pix[d+3], src.Pix[s+3])
+	}
+}
+
+// light replaces the colors of img with the palette colors of the ICM row.
+func (r *Renderer) light(img *image.RGBA, brightness uint8) error {
+	if r.ICM == nil {
+		return nil
+	}
+	row, err := r.ICM.Row(brightness)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i+3] == 0 {
+			continue
+		}
+		idx := row.IndexRGB(img.Pix[i], img.Pix[i+1], img.Pix[i+2])
+		if idx >= len(r.Palette) {
+			continue
+		}
+		c := color.RGBAModel.Convert(r.Palette[idx]).(color.RGBA)
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2] = c.R, c.G, c.B
+	}
+	return nil
+}
+
+func clone(img *image.RGBA) *image.RGBA {
+	res := image.NewRGBA(img.Rect)
+	copy(res.Pix, img.Pix)
+	return res
+}
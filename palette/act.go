@@ -0,0 +1,77 @@
+package palette
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+const (
+	actColors    = 256
+	actSize      = actColors * 3
+	actTrailSize = 4 // color count and transparent index
+	actNoAlpha   = 0xffff
+)
+
+func isACT(data []byte) bool {
+	return len(data) == actSize || len(data) == actSize+actTrailSize
+}
+
+// ParseACT parses an Adobe color table (.act). If the file has the optional
+// trailer, the palette is truncated to the stored color count and the
+// transparent index (if any) is set to a fully transparent color.
+func ParseACT(rd io.Reader) (color.Palette, error) {
+	var data [actSize + actTrailSize]byte
+	n, err := io.ReadFull(rd, data[:])
+	if err != nil && (err != io.ErrUnexpectedEOF || n != actSize) {
+		return nil, fmt.Errorf("failed to read color table: %w", err)
+	}
+
+	numColors, transparent := actColors, actNoAlpha
+	if n == len(data) {
+		numColors = int(binary.BigEndian.Uint16(data[actSize:]))
+		transparent = int(binary.BigEndian.Uint16(data[actSize+2:]))
+		if numColors == 0 || numColors > actColors {
+			numColors = actColors
+		}
+	}
+
+	pal := make(color.Palette, numColors)
+	for i := range pal {
+		pal[i] = color.RGBA{R: data[i*3], G: data[i*3+1], B: data[i*3+2], A: 255}
+	}
+	if transparent < numColors {
+		pal[transparent] = color.NRGBA{}
+	}
+	return pal, nil
+}
+
+// EncodeACT writes pal as Adobe color table. The trailer is written if the
+// palette has less than 256 colors or a fully transparent color. Empty
+// palettes cannot be stored, a color count of 0 is read as 256 colors.
+func EncodeACT(w io.Writer, pal color.Palette) error {
+	if len(pal) == 0 {
+		return ErrEmptyPalette
+	}
+	if len(pal) > actColors {
+		return fmt.Errorf("palette: too many colors for ACT: %d", len(pal))
+	}
+	var data [actSize + actTrailSize]byte
+	transparent := actNoAlpha
+	for i := range pal {
+		c := toNRGBA(pal[i])
+		if c.A == 0 && transparent == actNoAlpha {
+			transparent = i
+		}
+		data[i*3], data[i*3+1], data[i*3+2] = c.R, c.G, c.B
+	}
+	if len(pal) == actColors && transparent == actNoAlpha {
+		_, err := w.Write(data[:actSize])
+		return err
+	}
+	binary.BigEndian.PutUint16(data[actSize:], uint16(len(pal)))
+	binary.BigEndian.PutUint16(data[actSize+2:], uint16(transparent))
+	_, err := w.Write(data[:])
+	return err
+}
@@ -0,0 +1,124 @@
+package palette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"sync"
+)
+
+// Format is the name of a palette file format.
+type Format string
+
+const (
+	FormatJASC Format = "jasc" // JASC-PAL, used by the game itself
+	FormatGPL  Format = "gpl"  // GIMP palette
+	FormatACT  Format = "act"  // Adobe color table (Photoshop)
+	FormatPNG  Format = "png"  // 16x16 PNG swatch
+)
+
+var (
+	ErrUnknownFormat = errors.New("palette: unknown format")
+	ErrEmptyPalette  = errors.New("palette: empty palette")
+)
+
+type format struct {
+	name   Format
+	match  func(data []byte) bool
+	decode func(io.Reader) (color.Palette, error)
+	encode func(io.Writer, color.Palette) error
+
+	// fallback formats have no magic and are only matched if no other
+	// format matches
+	fallback bool
+}
+
+var (
+	formatsMu sync.Mutex
+	formats   []format
+)
+
+// RegisterFormat registers a palette format for use by Decode and Encode.
+// match reports whether the (complete) file contents are in this format.
+func RegisterFormat(name Format,
+	match func(data []byte) bool,
+	decode func(io.Reader) (color.Palette, error),
+	encode func(io.Writer, color.Palette) error,
+) {
+	register(format{name: name, match: match, decode: decode, encode: encode})
+}
+
+func register(f format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats = append(formats, f)
+}
+
+func lookupFormat(name Format) (format, bool) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for _, f := range formats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return format{}, false
+}
+
+func sniff(data []byte) (format, bool) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for _, fallback := range []bool{false, true} {
+		for _, f := range formats {
+			if f.fallback == fallback && f.match(data) {
+				return f, true
+			}
+		}
+	}
+	return format{}, false
+}
+
+// Decode decodes a palette in any registered format. The format is detected
+// from the file contents and returned along with the palette. ACT files have
+// no magic, so a file of ACT size is only read as ACT if no other format
+// matches.
+func Decode(rd io.Reader) (color.Palette, Format, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, "", err
+	}
+	f, ok := sniff(data)
+	if !ok {
+		return nil, "", ErrUnknownFormat
+	}
+	pal, err := f.decode(bytes.NewReader(data))
+	return pal, f.name, err
+}
+
+// Encode writes pal to w in the given format.
+func Encode(w io.Writer, pal color.Palette, name Format) error {
+	f, ok := lookupFormat(name)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+	}
+	return f.encode(w, pal)
+}
+
+func init() {
+	RegisterFormat(FormatJASC,
+		func(data []byte) bool { return bytes.HasPrefix(data, []byte(Header)) },
+		Parse,
+		func(w io.Writer, pal color.Palette) error {
+			data, err := Marshal(pal)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+	RegisterFormat(FormatGPL, isGPL, ParseGPL, EncodeGPL)
+	RegisterFormat(FormatPNG, isPNG, DecodePNG, EncodePNG)
+	register(format{name: FormatACT, match: isACT, decode: ParseACT, encode: EncodeACT, fallback: true})
+}
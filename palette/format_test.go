@@ -0,0 +1,118 @@
+package palette_test
+
+import (
+	"bytes"
+	"image/color"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, format := range []palette.Format{
+		palette.FormatJASC,
+		palette.FormatGPL,
+		palette.FormatACT,
+		palette.FormatPNG,
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if !assert.NoError(t, palette.Encode(&buf, palette.Default, format)) {
+				return
+			}
+			pal, detected, err := palette.Decode(&buf)
+			if assert.NoError(t, err) {
+				assert.Equal(t, format, detected)
+				assert.Equal(t, palette.Default, pal)
+			}
+		})
+	}
+}
+
+func TestJASCAlpha(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{R: 1, G: 2, B: 3, A: 255},
+		color.NRGBA{R: 4, G: 5, B: 6, A: 7},
+	}
+	data, err := palette.Marshal(pal)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "JASC-PAL\r\n0100\r\n2\r\n1 2 3 255\r\n4 5 6 7\r\n", string(data))
+
+	res, err := palette.Parse(bytes.NewReader(data))
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal, res)
+	}
+}
+
+func TestParseGPL(t *testing.T) {
+	const input = "GIMP Palette\nName: test\nColumns: 2\n# comment\n  0  10 255\tFirst\n 1 2 3 multi word name\n"
+	pal, format, err := palette.Decode(strings.NewReader(input))
+	if assert.NoError(t, err) {
+		assert.Equal(t, palette.FormatGPL, format)
+		assert.Equal(t, color.Palette{
+			color.RGBA{R: 0, G: 10, B: 255, A: 255},
+			color.RGBA{R: 1, G: 2, B: 3, A: 255},
+		}, pal)
+	}
+}
+
+func TestACTTrailer(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{R: 1, G: 2, B: 3, A: 255},
+		color.NRGBA{},
+	}
+	var buf bytes.Buffer
+	if !assert.NoError(t, palette.EncodeACT(&buf, pal)) {
+		return
+	}
+	assert.Equal(t, 772, buf.Len())
+	res, err := palette.ParseACT(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pal, res)
+	}
+
+	buf.Reset()
+	assert.ErrorIs(t, palette.EncodeACT(&buf, color.Palette{}), palette.ErrEmptyPalette)
+	assert.ErrorIs(t, palette.Encode(&buf, nil, palette.FormatACT), palette.ErrEmptyPalette)
+	assert.Zero(t, buf.Len())
+}
+
+func TestDecodeACTSize(t *testing.T) {
+	// text palettes of ACT size must not be read as ACT
+	jasc := "JASC-PAL\r\n0100\r\n1\r\n1 2 3\r\n"
+	jasc += strings.Repeat(" ", 768-len(jasc))
+	gpl := "GIMP Palette\n1 2 3\n"
+	gpl += strings.Repeat("#", 772-len(gpl))
+	want := color.Palette{color.RGBA{R: 1, G: 2, B: 3, A: 255}}
+
+	for input, format := range map[string]palette.Format{jasc: palette.FormatJASC, gpl: palette.FormatGPL} {
+		pal, detected, err := palette.Decode(strings.NewReader(input))
+		if assert.NoError(t, err) {
+			assert.Equal(t, format, detected)
+			assert.Equal(t, want, pal)
+		}
+	}
+
+	palette.RegisterFormat("late",
+		func(data []byte) bool { return strings.HasPrefix(string(data), "LATE") },
+		func(io.Reader) (color.Palette, error) { return want, nil },
+		nil)
+	_, detected, err := palette.Decode(strings.NewReader("LATE" + strings.Repeat(" ", 764)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, palette.Format("late"), detected)
+	}
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	_, _, err := palette.Decode(strings.NewReader("nope"))
+	assert.ErrorIs(t, err, palette.ErrUnknownFormat)
+
+	err = palette.Encode(&bytes.Buffer{}, palette.Default, "bmp")
+	assert.ErrorIs(t, err, palette.ErrUnknownFormat)
+}
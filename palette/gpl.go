@@ -0,0 +1,66 @@
+package palette
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+const gplHeader = "GIMP Palette"
+
+func isGPL(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(gplHeader))
+}
+
+// ParseGPL parses a GIMP palette.
+func ParseGPL(rd io.Reader) (color.Palette, error) {
+	s := bufio.NewScanner(rd)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		return nil, fmt.Errorf("failed to read header: %w", io.EOF)
+	}
+	if ln := strings.TrimSpace(s.Text()); ln != gplHeader {
+		return nil, &ParseError{Line: 1, Err: fmt.Errorf("%w: %q", ErrInvalidHeader, ln)}
+	}
+
+	var pal color.Palette
+	for lineNr := 2; s.Scan(); lineNr++ {
+		ln := strings.TrimSpace(s.Text())
+		switch {
+		case ln == "", strings.HasPrefix(ln, "#"),
+			strings.HasPrefix(ln, "Name:"), strings.HasPrefix(ln, "Columns:"):
+			continue
+		}
+		// the color name is optional and may contain spaces
+		fields := strings.Fields(ln)
+		if len(fields) < 3 {
+			return nil, &ParseError{Line: lineNr, Err: fmt.Errorf("%w: expected 3 values, got %d", ErrInvalidLine, len(fields))}
+		}
+		col, err := parseColor(fields[:3])
+		if err != nil {
+			return nil, &ParseError{Line: lineNr, Err: fmt.Errorf("%w: %w", ErrInvalidLine, err)}
+		}
+		pal = append(pal, col)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return pal, nil
+}
+
+// EncodeGPL writes pal as GIMP palette. GPL has no alpha channel, so
+// translucent colors lose their alpha value.
+func EncodeGPL(w io.Writer, pal color.Palette) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s\nName: genie\nColumns: 16\n#\n", gplHeader)
+	for i := range pal {
+		c := toNRGBA(pal[i])
+		fmt.Fprintf(bw, "%3d %3d %3d\tIndex %d\n", c.R, c.G, c.B, i)
+	}
+	return bw.Flush()
+}
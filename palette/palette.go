@@ -8,6 +8,7 @@ import (
 	"image/color"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -20,55 +21,157 @@ var (
 	ErrInvalidVersion = errors.New("invalid version")
 	ErrInvalidHeader  = errors.New("invalid header")
 	ErrInvalidLine    = errors.New("invalid line")
+	ErrOutOfRange     = errors.New("value out of range")
+	ErrCountMismatch  = errors.New("color count mismatch")
 )
 
-// Parse parses a JASC palette.
+// Mode selects how forgiving the parser is.
+type Mode int
+
+const (
+	// Strict accepts only well-formed files: no comments, no blank lines
+	// and exactly as many colors as declared.
+	Strict Mode = iota
+	// Lenient skips blank lines and '#' comments and accepts fewer or
+	// more color lines than declared. Extra lines are ignored.
+	Lenient
+)
+
+type (
+	ParseOptions struct {
+		Mode Mode
+	}
+
+	// ParseError is returned if a palette file is malformed.
+	ParseError struct {
+		Line int // the line number, starting at 1
+		Err  error
+	}
+)
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("palette: line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses a JASC palette in strict mode.
 func Parse(rd io.Reader) (color.Palette, error) {
+	return ParseOptions{}.Parse(rd)
+}
+
+// Parse parses a JASC palette.
+func (opts ParseOptions) Parse(rd io.Reader) (color.Palette, error) {
 	brd, ok := rd.(*bufio.Reader)
 	if !ok {
 		brd = bufio.NewReader(rd)
 	}
 
+	lineNr := 0
+	// readLine returns the next line. If eol is set, the line must be
+	// terminated by a newline, otherwise it may end at EOF.
+	readLine := func(eol bool) (string, error) {
+		ln, err := brd.ReadString('\n')
+		lineNr++
+		if err != nil && (eol || err != io.EOF || ln == "") {
+			return "", &ParseError{Line: lineNr, Err: err}
+		}
+		if opts.Mode == Lenient {
+			ln, _, _ = strings.Cut(ln, "#")
+		}
+		return strings.TrimSpace(ln), nil
+	}
+	fail := func(err error) (color.Palette, error) {
+		return nil, &ParseError{Line: lineNr, Err: err}
+	}
+
 	// JASC-PAL
-	ln, err := brd.ReadString('\n')
+	ln, err := readLine(true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read header: %w", err)
 	}
-	if strings.TrimSpace(ln) != Header {
-		return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, strings.TrimSpace(ln))
+	if ln != Header {
+		return fail(fmt.Errorf("%w: %q", ErrInvalidHeader, ln))
 	}
 	// 0100
-	ln, err = brd.ReadString('\n')
+	ln, err = readLine(true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read version: %w", err)
 	}
-	ver := strings.TrimSpace(ln)
-	if ver != palVersion {
-		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, ver)
+	if ln != palVersion {
+		return fail(fmt.Errorf("%w: %q", ErrInvalidVersion, ln))
 	}
 
-	var numColors int
-	if _, err := fmt.Fscanf(brd, "%d\n", &numColors); err != nil {
+	ln, err = readLine(false)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse color count: %w", err)
 	}
+	numColors, err := strconv.ParseUint(ln, 10, 16)
+	if err != nil {
+		return fail(fmt.Errorf("%w: invalid color count: %w", ErrInvalidLine, err))
+	}
 
-	lineNr := 3
-	pal := make(color.Palette, numColors)
-	for i := 0; i < int(numColors); i++ {
-		var col color.RGBA
-		col.A = 255
+	pal := make(color.Palette, 0, min(numColors, 256))
+	for len(pal) < int(numColors) {
+		ln, err := readLine(false)
+		if errors.Is(err, io.EOF) {
+			if opts.Mode == Lenient {
+				break
+			}
+			return fail(fmt.Errorf("%w: expected %d colors, got %d: %w", ErrCountMismatch, numColors, len(pal), io.EOF))
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidLine, err)
+		}
+		if ln == "" && opts.Mode == Lenient {
+			continue
+		}
 
-		_, err := fmt.Fscanf(brd, "%d %d %d\n", &col.R, &col.G, &col.B)
+		col, err := parseColor(strings.Fields(ln))
 		if err != nil {
-			return nil, fmt.Errorf("%w: failed to parse line %d: %w", ErrInvalidLine, i, err)
+			return fail(fmt.Errorf("%w: %w", ErrInvalidLine, err))
 		}
+		pal = append(pal, col)
+	}
 
-		lineNr++
-		pal[i] = col
+	if opts.Mode == Strict {
+		// only trailing whitespace is allowed after the last color
+		rest, err := io.ReadAll(brd)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(rest)) != 0 {
+			return nil, &ParseError{Line: lineNr + 1, Err: fmt.Errorf("%w: more than %d colors", ErrCountMismatch, numColors)}
+		}
 	}
 	return pal, nil
 }
 
+// parseColor parses the fields of a color line. The alpha column is optional,
+// colors without one are fully opaque. Opaque colors are returned as
+// color.RGBA, translucent ones as color.NRGBA.
+func parseColor(fields []string) (color.Color, error) {
+	if len(fields) != 3 && len(fields) != 4 {
+		return nil, fmt.Errorf("expected 3 or 4 values, got %d", len(fields))
+	}
+	var v [4]uint8
+	v[3] = 255
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 8)
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, fmt.Errorf("%w: %s", ErrOutOfRange, f)
+		} else if err != nil {
+			return nil, err
+		}
+		v[i] = uint8(n)
+	}
+	if v[3] == 255 {
+		return color.RGBA{R: v[0], G: v[1], B: v[2], A: 255}, nil
+	}
+	return color.NRGBA{R: v[0], G: v[1], B: v[2], A: v[3]}, nil
+}
+
 // Open a JASC palette from the local filesystem.
 func Open(filename string) (color.Palette, error) {
 	fh, err := os.Open(filename)
@@ -80,15 +183,36 @@ func Open(filename string) (color.Palette, error) {
 }
 
 // Marshal returns the given palette JASC file.
+//
+// An alpha column is only written if the palette contains translucent colors.
 func Marshal(pal color.Palette) ([]byte, error) {
 	var buf bytes.Buffer
 
 	buf.WriteString(Header)
 	buf.WriteString("\r\n0100\r\n")
 	fmt.Fprintf(&buf, "%d\r\n", len(pal))
+	withAlpha := hasAlpha(pal)
 	for i := range pal {
-		r, g, b, _ := pal[i].RGBA()
-		fmt.Fprintf(&buf, "%d %d %d\r\n", r>>8, g>>8, b>>8)
+		c := toNRGBA(pal[i])
+		if withAlpha {
+			fmt.Fprintf(&buf, "%d %d %d %d\r\n", c.R, c.G, c.B, c.A)
+		} else {
+			fmt.Fprintf(&buf, "%d %d %d\r\n", c.R, c.G, c.B)
+		}
 	}
 	return buf.Bytes(), nil
 }
+
+func toNRGBA(c color.Color) color.NRGBA {
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
+
+// hasAlpha returns true if any color of pal is not fully opaque.
+func hasAlpha(pal color.Palette) bool {
+	for _, c := range pal {
+		if _, _, _, a := c.RGBA(); a != 0xffff {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"testing/iotest"
 
@@ -105,3 +106,45 @@ func BenchmarkParse(b *testing.B) {
 		palette.Parse(bytes.NewReader(data))
 	}
 }
+
+func TestParseErrorLine(t *testing.T) {
+	var perr *palette.ParseError
+
+	_, err := palette.Open("./testdata/invalid_line.txt")
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, 20, perr.Line)
+	}
+	assert.ErrorIs(t, err, palette.ErrInvalidLine)
+	assert.ErrorIs(t, err, palette.ErrOutOfRange)
+
+	_, err = palette.Open("./testdata/invalid_header.txt")
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, 1, perr.Line)
+	}
+
+	_, err = palette.Open("./testdata/missing_color.txt")
+	assert.ErrorIs(t, err, palette.ErrCountMismatch)
+}
+
+func TestParseModes(t *testing.T) {
+	const input = "JASC-PAL\n0100\n3\n# a comment\n1 2 3   # red-ish\n\n  4  5  6  \n"
+
+	_, err := palette.Parse(strings.NewReader(input))
+	var perr *palette.ParseError
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, 4, perr.Line)
+	}
+
+	pal, err := palette.ParseOptions{Mode: palette.Lenient}.Parse(strings.NewReader(input))
+	if assert.NoError(t, err) {
+		assert.Len(t, pal, 2)
+	}
+
+	_, err = palette.Parse(strings.NewReader("JASC-PAL\n0100\n1\n1 2 3\n4 5 6\n"))
+	assert.ErrorIs(t, err, palette.ErrCountMismatch)
+
+	pal, err = palette.ParseOptions{Mode: palette.Lenient}.Parse(strings.NewReader("JASC-PAL\n0100\n1\n1 2 3\n4 5 6\n"))
+	if assert.NoError(t, err) {
+		assert.Len(t, pal, 1)
+	}
+}
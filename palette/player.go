@@ -0,0 +1,149 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PlayerColors describes which palette entries are player colors.
+//
+// The graphics are drawn with the colors of player 0. The colors of any other
+// player are found at the same position in that player's range, which is
+// Stride entries after the range of the previous player.
+//
+// DE does not use ranges of the main palette, every player has a palette of
+// its own instead (see NewPlayerPalettes).
+type PlayerColors struct {
+	Base    int // index of the first color of player 0
+	Stride  int // distance between the ranges of two consecutive players
+	Count   int // number of colors per player
+	Players int // number of players
+
+	Palettes []color.Palette // DE: one palette per player, indexed directly
+}
+
+var (
+	// PlayerColorsAoK are the player colors of all editions before DE. Age
+	// of Empires, Rise of Rome, The Age of Kings, The Conquerors and HD use
+	// the same ranges.
+	PlayerColorsAoK = PlayerColors{Base: 16, Stride: 16, Count: 8, Players: 8}
+
+	// DefaultPlayerColors are the player colors used for Default.
+	DefaultPlayerColors = PlayerColorsAoK
+)
+
+// NewPlayerPalettes returns the player colors for DE, where the index of a
+// player color pixel refers to a separate palette per player.
+func NewPlayerPalettes(pals []color.Palette) PlayerColors {
+	pc := PlayerColors{Players: len(pals), Palettes: pals}
+	for i, pal := range pals {
+		if i == 0 || len(pal) < pc.Count {
+			pc.Count = len(pal)
+		}
+	}
+	return pc
+}
+
+// Valid returns true if player is one of the players.
+func (pc PlayerColors) Valid(player int) bool {
+	return player >= 0 && player < pc.Players
+}
+
+// Contains returns true if index is a player color of any player.
+func (pc PlayerColors) Contains(index uint8) bool {
+	_, _, ok := pc.Player(index)
+	return ok
+}
+
+// Player returns the player and the offset in the range of that player for
+// the given palette index.
+func (pc PlayerColors) Player(index uint8) (player, offset int, ok bool) {
+	if pc.Palettes != nil || pc.Stride <= 0 {
+		return 0, 0, false
+	}
+	i := int(index) - pc.Base
+	if i < 0 {
+		return 0, 0, false
+	}
+	player, offset = i/pc.Stride, i%pc.Stride
+	if player >= pc.Players || offset >= pc.Count {
+		return 0, 0, false
+	}
+	return player, offset, true
+}
+
+// Remap returns the palette index of the player color index (as stored in the
+// graphics, that is in the range of player 0) for the given player.
+//
+// With separate player palettes or an invalid player the index is returned
+// unchanged.
+func (pc PlayerColors) Remap(index uint8, player int) uint8 {
+	if pc.Palettes != nil || !pc.Valid(player) {
+		return index
+	}
+	return uint8(int(index) + player*pc.Stride)
+}
+
+// Color returns the color of the player color index for the given player.
+// Invalid players get the colors of player 0.
+func (pc PlayerColors) Color(pal color.Palette, index uint8, player int) color.Color {
+	if !pc.Valid(player) {
+		player = 0
+	}
+	if pc.Palettes != nil {
+		return pc.Palettes[player][index]
+	}
+	return pal[pc.Remap(index, player)]
+}
+
+// Recolor replaces the colors of player from with the colors of player to.
+//
+// Paletted images are recolored by index. For any other image the pixels are
+// compared against the player colors in pal.
+// Nothing is changed if one of the players is invalid.
+func (pc PlayerColors) Recolor(img draw.Image, pal color.Palette, from, to int) {
+	if from == to || !pc.Valid(from) || !pc.Valid(to) {
+		return
+	}
+	b := img.Bounds()
+
+	if p, ok := img.(*image.Paletted); ok && pc.Palettes == nil {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				i := p.ColorIndexAt(x, y)
+				if player, offset, ok := pc.Player(i); ok && player == from {
+					p.SetColorIndex(x, y, pc.Remap(uint8(pc.Base+offset), to))
+				}
+			}
+		}
+		return
+	}
+
+	// build a lookup from the colors of player `from` to those of player `to`
+	lut := make(map[color.RGBA]color.Color, pc.Count)
+	for i := 0; i < pc.Count; i++ {
+		idx := uint8(pc.Base + i)
+		if pc.Palettes != nil {
+			idx = uint8(i)
+		}
+		src := color.RGBAModel.Convert(pc.Color(pal, idx, from)).(color.RGBA)
+		if _, found := lut[src]; !found {
+			lut[src] = pc.Color(pal, idx, to)
+		}
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if dst, found := lut[c]; found {
+				img.Set(x, y, dst)
+			}
+		}
+	}
+}
+
+// Recolor replaces the colors of player from with the colors of player to,
+// using the default palette and player colors.
+func Recolor(img draw.Image, from, to int) {
+	DefaultPlayerColors.Recolor(img, Default, from, to)
+}
@@ -0,0 +1,83 @@
+package palette_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayerColors(t *testing.T) {
+	pc := palette.PlayerColorsAoK
+
+	assert.Equal(t, uint8(16+2), pc.Remap(16+2, 0))
+	assert.Equal(t, uint8(16+3*16+2), pc.Remap(16+2, 3))
+
+	player, offset, ok := pc.Player(16 + 3*16 + 2)
+	if assert.True(t, ok) {
+		assert.Equal(t, 3, player)
+		assert.Equal(t, 2, offset)
+	}
+	assert.False(t, pc.Contains(15))
+	assert.False(t, pc.Contains(16+8))            // gap between the ranges
+	assert.False(t, pc.Contains(16+8*16))         // past the last player
+	assert.True(t, pc.Contains(uint8(16+7*16+7))) // last color of the last player
+}
+
+func TestPlayerColorsInvalidPlayer(t *testing.T) {
+	pc := palette.PlayerColorsAoK
+	assert.False(t, pc.Valid(-1))
+	assert.False(t, pc.Valid(8))
+	assert.Equal(t, uint8(16+2), pc.Remap(16+2, 8))
+	assert.Equal(t, uint8(16+2), pc.Remap(16+2, -1))
+	assert.Equal(t, palette.Default[16+2], pc.Color(palette.Default, 16+2, 20))
+
+	de := palette.NewPlayerPalettes([]color.Palette{{color.Black}, {color.White}})
+	assert.Equal(t, color.Black, de.Color(nil, 0, 2))
+
+	img := image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Default)
+	img.SetColorIndex(0, 0, 16+1)
+	pc.Recolor(img, nil, 0, 9)
+	assert.Equal(t, []uint8{16 + 1}, img.Pix)
+}
+
+func TestRecolor(t *testing.T) {
+	pc := palette.PlayerColorsAoK
+
+	t.Run("paletted", func(t *testing.T) {
+		img := image.NewPaletted(image.Rect(0, 0, 3, 1), palette.Default)
+		img.SetColorIndex(0, 0, 16+1)
+		img.SetColorIndex(1, 0, 1)
+		img.SetColorIndex(2, 0, 16+16+1) // player 1, must stay
+
+		pc.Recolor(img, nil, 0, 2)
+		assert.Equal(t, []uint8{16 + 2*16 + 1, 1, 16 + 16 + 1}, img.Pix)
+	})
+
+	t.Run("rgba", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+		img.Set(0, 0, palette.Default[16+4])
+		img.Set(1, 0, palette.Default[1])
+
+		palette.Recolor(img, 0, 1)
+		assert.Equal(t, palette.Default[16+16+4], color.Color(img.RGBAAt(0, 0)))
+		assert.Equal(t, palette.Default[1], color.Color(img.RGBAAt(1, 0)))
+	})
+
+	t.Run("separate palettes", func(t *testing.T) {
+		pals := []color.Palette{
+			{color.RGBA{R: 1, A: 255}, color.RGBA{R: 2, A: 255}},
+			{color.RGBA{G: 1, A: 255}, color.RGBA{G: 2, A: 255}},
+		}
+		de := palette.NewPlayerPalettes(pals)
+		assert.Equal(t, pals[1][1], de.Color(nil, 1, 1))
+
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		img.Set(0, 0, pals[0][1])
+		de.Recolor(img, nil, 0, 1)
+		assert.Equal(t, pals[1][1], color.Color(img.RGBAAt(0, 0)))
+	})
+}
@@ -0,0 +1,59 @@
+package palette
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const swatchSize = 16 // the swatch is a grid of 16x16 cells
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+func isPNG(data []byte) bool {
+	return bytes.HasPrefix(data, pngMagic)
+}
+
+// DecodePNG reads a palette from a PNG swatch: a grid of 16x16 equally sized
+// cells, one per color, in row-major order. The color of each cell is taken
+// from its center pixel.
+func DecodePNG(rd io.Reader) (color.Palette, error) {
+	img, err := png.Decode(rd)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if b.Dx() < swatchSize || b.Dy() < swatchSize ||
+		b.Dx()%swatchSize != 0 || b.Dy()%swatchSize != 0 {
+		return nil, fmt.Errorf("palette: invalid swatch size %dx%d", b.Dx(), b.Dy())
+	}
+	cw, ch := b.Dx()/swatchSize, b.Dy()/swatchSize
+
+	pal := make(color.Palette, swatchSize*swatchSize)
+	for i := range pal {
+		x := b.Min.X + (i%swatchSize)*cw + cw/2
+		y := b.Min.Y + (i/swatchSize)*ch + ch/2
+		c := toNRGBA(img.At(x, y))
+		if c.A == 255 {
+			pal[i] = color.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+		} else {
+			pal[i] = c
+		}
+	}
+	return pal, nil
+}
+
+// EncodePNG writes pal as 16x16 pixel PNG swatch. Unused cells are transparent.
+func EncodePNG(w io.Writer, pal color.Palette) error {
+	if len(pal) > swatchSize*swatchSize {
+		return fmt.Errorf("palette: too many colors for PNG swatch: %d", len(pal))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, swatchSize, swatchSize))
+	for i := range pal {
+		img.SetNRGBA(i%swatchSize, i/swatchSize, toNRGBA(pal[i]))
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,309 @@
+package palette
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/icm"
+)
+
+// Range is a range [Start, End) of palette indices.
+type Range struct {
+	Start, End int
+}
+
+// Contains returns true if index is within the range.
+func (r Range) Contains(index int) bool {
+	return index >= r.Start && index < r.End
+}
+
+var (
+	ErrTooManyColors = errors.New("palette: too many colors")
+	ErrNoColors      = errors.New("palette: all colors are excluded")
+)
+
+var (
+	// Reserved are the system reserved colors at the beginning and end of the
+	// palette. They are not used by the game graphics.
+	Reserved = []Range{{Start: 0, End: 10}, {Start: 246, End: 256}}
+)
+
+// Ranges returns the palette ranges of all player colors.
+func (pc PlayerColors) Ranges() []Range {
+	if pc.Palettes != nil {
+		return nil
+	}
+	res := make([]Range, pc.Players)
+	for i := range res {
+		start := pc.Base + i*pc.Stride
+		res[i] = Range{Start: start, End: start + pc.Count}
+	}
+	return res
+}
+
+// Quantizer maps arbitrary colors to the perceptually nearest color of a
+// palette, measured as distance in CIELAB space.
+//
+// It implements draw.Quantizer and draw.Drawer, so it can be used for
+// encoding GIFs or for converting images to palette indices with draw.Draw
+// semantics. Lookups are cached, so the fields must not be changed after the
+// first use. A Quantizer is safe for concurrent use.
+//
+// The palette must have at most 256 colors and at least one of them must not
+// be excluded, see Validate.
+type Quantizer struct {
+	Palette color.Palette // the target palette; Default if nil
+	Dither  bool          // use Floyd-Steinberg error diffusion
+	Exclude []Range       // palette indices that are never chosen
+
+	// ICM is an optional inverse color map used to speed up the lookup. The
+	// ICM result is only used if it is not excluded.
+	ICM        *icm.Map
+	Brightness uint8 // the ICM row to use
+
+	once  sync.Once
+	err   error
+	lab   []lab
+	mu    sync.Mutex
+	cache map[color.RGBA]uint8
+}
+
+var (
+	_ draw.Quantizer = (*Quantizer)(nil)
+	_ draw.Drawer    = (*Quantizer)(nil)
+)
+
+func (q *Quantizer) palette() color.Palette {
+	if q.Palette == nil {
+		return Default
+	}
+	return q.Palette
+}
+
+func (q *Quantizer) excluded(index int) bool {
+	for _, r := range q.Exclude {
+		if r.Contains(index) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Quantizer) init() error {
+	q.once.Do(func() {
+		pal := q.palette()
+		if len(pal) > 256 {
+			q.err = fmt.Errorf("%w: %d", ErrTooManyColors, len(pal))
+			return
+		}
+		eligible := false
+		q.lab = make([]lab, len(pal))
+		for i, c := range pal {
+			q.lab[i] = toLab(color.RGBAModel.Convert(c).(color.RGBA))
+			eligible = eligible || !q.excluded(i)
+		}
+		if !eligible {
+			q.err = ErrNoColors
+			return
+		}
+		q.cache = make(map[color.RGBA]uint8)
+	})
+	return q.err
+}
+
+// Validate returns ErrTooManyColors if the palette has more than 256 colors
+// and ErrNoColors if all of its colors are excluded.
+func (q *Quantizer) Validate() error {
+	return q.init()
+}
+
+// Index returns the palette index of the color nearest to c, or -1 if the
+// quantizer is invalid (see Validate). Translucent colors are matched by
+// their color only.
+func (q *Quantizer) Index(c color.Color) int {
+	if q.init() != nil {
+		return -1
+	}
+	return int(q.index(toNRGBA(c)))
+}
+
+func (q *Quantizer) index(c color.NRGBA) uint8 {
+	key := color.RGBA{R: c.R, G: c.G, B: c.B, A: 255}
+	q.mu.Lock()
+	idx, found := q.cache[key]
+	q.mu.Unlock()
+	if found {
+		return idx
+	}
+	idx = q.nearest(key)
+	q.mu.Lock()
+	q.cache[key] = idx
+	q.mu.Unlock()
+	return idx
+}
+
+func (q *Quantizer) nearest(key color.RGBA) uint8 {
+	if q.ICM != nil {
+		if idx, err := q.ICM.CheckedIndex(q.Brightness, key); err == nil && !q.excluded(idx) {
+			return uint8(idx)
+		}
+	}
+
+	want := toLab(key)
+	best, bestDist := 0, math.Inf(1)
+	for i, l := range q.lab {
+		if q.excluded(i) {
+			continue
+		}
+		if d := want.dist(l); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return uint8(best)
+}
+
+// Quantize implements draw.Quantizer. The palette is fixed, so it appends the
+// colors of the target palette (as far as p has capacity) regardless of m.
+func (q *Quantizer) Quantize(p color.Palette, m image.Image) color.Palette {
+	pal := q.palette()
+	n := min(cap(p)-len(p), len(pal))
+	return append(p, pal[:n]...)
+}
+
+// Draw implements draw.Drawer. Fully transparent source pixels are skipped.
+// If dst is paletted with the target palette, the indices are set directly,
+// otherwise the colors are set with dst.Set. Nothing is drawn if the
+// quantizer is invalid (see Validate).
+func (q *Quantizer) Draw(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	if q.init() != nil {
+		return
+	}
+	pal := q.palette()
+
+	// clip like draw.Draw does
+	orig := r.Min
+	r = r.Intersect(dst.Bounds())
+	r = r.Intersect(src.Bounds().Add(orig.Sub(sp)))
+	if r.Empty() {
+		return
+	}
+	sp = sp.Add(r.Min.Sub(orig))
+
+	paletted, _ := dst.(*image.Paletted)
+	if paletted != nil && !samePalette(paletted.Palette, pal) {
+		paletted = nil
+	}
+
+	// quantization errors of the current and next line, scaled by 16.
+	// The extra element on both sides avoids bounds checks.
+	var cur, next [][3]int32
+	if q.Dither {
+		cur = make([][3]int32, r.Dx()+2)
+		next = make([][3]int32, r.Dx()+2)
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := toNRGBA(src.At(sp.X+x-r.Min.X, sp.Y+y-r.Min.Y))
+			if c.A == 0 {
+				continue
+			}
+			i := x - r.Min.X + 1
+
+			var want [3]int32
+			if q.Dither {
+				want = [3]int32{
+					clamp8(int32(c.R) + cur[i][0]/16),
+					clamp8(int32(c.G) + cur[i][1]/16),
+					clamp8(int32(c.B) + cur[i][2]/16),
+				}
+				c.R, c.G, c.B = uint8(want[0]), uint8(want[1]), uint8(want[2])
+			}
+
+			idx := q.index(c)
+			if paletted != nil {
+				paletted.SetColorIndex(x, y, idx)
+			} else {
+				dst.Set(x, y, pal[idx])
+			}
+
+			if q.Dither {
+				got := color.RGBAModel.Convert(pal[idx]).(color.RGBA)
+				e := [3]int32{
+					want[0] - int32(got.R),
+					want[1] - int32(got.G),
+					want[2] - int32(got.B),
+				}
+				for k := range e {
+					cur[i+1][k] += e[k] * 7
+					next[i-1][k] += e[k] * 3
+					next[i][k] += e[k] * 5
+					next[i+1][k] += e[k] * 1
+				}
+			}
+		}
+		if q.Dither {
+			cur, next = next, cur
+			clear(next)
+		}
+	}
+}
+
+// samePalette returns true if a and b have the same colors.
+func samePalette(a, b color.Palette) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ca := color.RGBAModel.Convert(a[i]).(color.RGBA)
+		cb := color.RGBAModel.Convert(b[i]).(color.RGBA)
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+func clamp8(v int32) int32 {
+	return max(0, min(255, v))
+}
+
+// lab is a color in CIELAB space (D65 white point).
+type lab struct {
+	L, A, B float64
+}
+
+func (l lab) dist(o lab) float64 {
+	dl, da, db := l.L-o.L, l.A-o.A, l.B-o.B
+	return dl*dl + da*da + db*db
+}
+
+func linearize(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func toLab(c color.RGBA) lab {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
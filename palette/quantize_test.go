@@ -0,0 +1,114 @@
+package palette_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantizerIndex(t *testing.T) {
+	q := &palette.Quantizer{}
+	for _, i := range []int{10, 16, 100, 200} {
+		assert.Equal(t, palette.Default[i], palette.Default[q.Index(palette.Default[i])])
+	}
+
+	// pure red is index 36; excluding the player colors must pick another one
+	q = &palette.Quantizer{Exclude: palette.PlayerColorsAoK.Ranges()}
+	red := color.RGBA{R: 255, A: 255}
+	idx := q.Index(red)
+	assert.False(t, palette.PlayerColorsAoK.Contains(uint8(idx)))
+}
+
+func TestQuantizerInvalid(t *testing.T) {
+	q := &palette.Quantizer{Exclude: []palette.Range{{Start: 0, End: 256}}}
+	assert.ErrorIs(t, q.Validate(), palette.ErrNoColors)
+	assert.Equal(t, -1, q.Index(color.Black))
+
+	dst := image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Default)
+	dst.SetColorIndex(0, 0, 7)
+	q.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{})
+	assert.Equal(t, uint8(7), dst.ColorIndexAt(0, 0))
+
+	q = &palette.Quantizer{Palette: make(color.Palette, 257)}
+	for i := range q.Palette {
+		q.Palette[i] = color.Black
+	}
+	assert.ErrorIs(t, q.Validate(), palette.ErrTooManyColors)
+	assert.Equal(t, -1, q.Index(color.Black))
+
+	assert.NoError(t, (&palette.Quantizer{}).Validate())
+}
+
+func TestQuantizerConcurrent(t *testing.T) {
+	q := &palette.Quantizer{}
+	want := q.Index(color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 256; i++ {
+				q.Index(color.RGBA{R: uint8(i), G: uint8(g), A: 255})
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, want, q.Index(color.RGBA{R: 200, G: 100, B: 50, A: 255}))
+}
+
+func TestQuantizerDraw(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 32), G: uint8(y * 32), B: 77, A: 255})
+		}
+	}
+	src.Set(0, 0, color.Transparent)
+
+	for _, dither := range []bool{false, true} {
+		q := &palette.Quantizer{Dither: dither, Exclude: palette.Reserved}
+		dst := image.NewPaletted(src.Bounds(), palette.Default)
+		dst.SetColorIndex(0, 0, 1)
+
+		q.Draw(dst, dst.Bounds(), src, image.Point{})
+
+		assert.Equal(t, uint8(1), dst.ColorIndexAt(0, 0), "transparent pixels are skipped")
+		for _, idx := range dst.Pix[1:] {
+			for _, r := range palette.Reserved {
+				assert.False(t, r.Contains(int(idx)))
+			}
+		}
+	}
+	var _ draw.Drawer = &palette.Quantizer{}
+}
+
+func TestQuantizerDrawOtherPalette(t *testing.T) {
+	// a blue ramp of the same length as the default palette
+	ramp := make(color.Palette, 256)
+	for i := range ramp {
+		ramp[i] = color.RGBA{B: uint8(i), A: 255}
+	}
+	src := image.NewUniform(color.RGBA{B: 200, A: 255})
+	dst := image.NewPaletted(image.Rect(0, 0, 1, 1), ramp)
+
+	q := &palette.Quantizer{}
+	q.Draw(dst, dst.Bounds(), src, image.Point{})
+	want := dst.Palette.Index(palette.Default[q.Index(src.C)])
+	assert.Equal(t, uint8(want), dst.ColorIndexAt(0, 0))
+
+	q = &palette.Quantizer{Palette: ramp}
+	q.Draw(dst, dst.Bounds(), src, image.Point{})
+	assert.Equal(t, uint8(200), dst.ColorIndexAt(0, 0))
+}
+
+func TestQuantize(t *testing.T) {
+	q := &palette.Quantizer{}
+	pal := q.Quantize(make(color.Palette, 0, 256), nil)
+	assert.Equal(t, palette.Default, pal)
+}
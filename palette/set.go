@@ -0,0 +1,201 @@
+package palette
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+)
+
+const (
+	// DefaultID is the ID of the default palette (see Default).
+	DefaultID = 50500
+
+	// ConfName is the name of the palette configuration file of DE.
+	ConfName = "palettes.conf"
+)
+
+// Set is a collection of palettes by ID.
+//
+// The IDs are the DRS file IDs of the palettes (e.g. 50500 in interfac.drs)
+// or the IDs in palettes.conf, which are the palette numbers referenced by
+// SLP and SMX frames. The zero value is an empty set.
+type Set struct {
+	palettes map[int]color.Palette
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{palettes: make(map[int]color.Palette)}
+}
+
+// Add adds pal with the given ID, replacing any existing palette.
+func (s *Set) Add(id int, pal color.Palette) {
+	if s.palettes == nil {
+		s.palettes = make(map[int]color.Palette)
+	}
+	s.palettes[id] = pal
+}
+
+// Get returns the palette with the given ID.
+func (s *Set) Get(id int) (pal color.Palette, found bool) {
+	pal, found = s.palettes[id]
+	return
+}
+
+// Lookup returns the palette with the given ID. If there is no such palette
+// it falls back to the palette with DefaultID, and to Default after that.
+func (s *Set) Lookup(id int) color.Palette {
+	if pal, found := s.palettes[id]; found {
+		return pal
+	}
+	if pal, found := s.palettes[DefaultID]; found {
+		return pal
+	}
+	return Default
+}
+
+// IDs returns the sorted IDs of all palettes.
+func (s *Set) IDs() []int {
+	ids := make([]int, 0, len(s.palettes))
+	for id := range s.palettes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Len returns the number of palettes.
+func (s *Set) Len() int {
+	return len(s.palettes)
+}
+
+// LoadDRS adds every JASC palette of the archive, using the file ID as
+// palette ID. Other files are ignored.
+func (s *Set) LoadDRS(rd *drs.Reader) error {
+	for _, file := range rd.Files {
+		data, err := file.Data()
+		if err != nil {
+			return fmt.Errorf("palette: failed to read file %s: %w", file.ID, err)
+		}
+		if !bytes.HasPrefix(data, []byte(Header)) {
+			continue
+		}
+		pal, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("palette: file %s: %w", file.ID, err)
+		}
+		s.Add(int(file.ID), pal)
+	}
+	return nil
+}
+
+// LoadFS adds every palette in dir whose name starts with its ID, e.g.
+// "50500.pal" or "50532_palette.txt". The format is detected by Decode,
+// files in an unknown format are ignored.
+func (s *Set) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		end := strings.IndexFunc(name, func(r rune) bool { return r < '0' || r > '9' })
+		if end < 0 {
+			end = len(name)
+		}
+		id, err := strconv.Atoi(name[:end])
+		if err != nil {
+			continue
+		}
+		pal, err := decodeFile(fsys, path.Join(dir, name))
+		if errors.Is(err, ErrUnknownFormat) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.Add(id, pal)
+	}
+	return nil
+}
+
+// LoadConf parses the palette configuration name (usually palettes.conf)
+// and adds the palettes it references. File names are relative to the
+// directory of the configuration.
+func (s *Set) LoadConf(fsys fs.FS, name string) error {
+	fh, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	conf, err := ParseConf(fh)
+	fh.Close()
+	if err != nil {
+		return fmt.Errorf("palette: %s: %w", name, err)
+	}
+
+	dir := path.Dir(name)
+	for id, fname := range conf {
+		pal, err := decodeFile(fsys, path.Join(dir, fname))
+		if err != nil {
+			return err
+		}
+		s.Add(id, pal)
+	}
+	return nil
+}
+
+func decodeFile(fsys fs.FS, name string) (color.Palette, error) {
+	fh, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	pal, _, err := Decode(fh)
+	if err != nil {
+		return nil, fmt.Errorf("palette: %s: %w", name, err)
+	}
+	return pal, nil
+}
+
+// ParseConf parses a palette configuration. Every line maps an ID to a file
+// name, separated by a comma:
+//
+//	// comment
+//	0,playercolor_blue.pal
+//	50500,standard.pal
+func ParseConf(rd io.Reader) (map[int]string, error) {
+	res := make(map[int]string)
+	s := bufio.NewScanner(rd)
+	for lineNr := 1; s.Scan(); lineNr++ {
+		ln := strings.TrimSpace(s.Text())
+		if ln == "" || strings.HasPrefix(ln, "//") || strings.HasPrefix(ln, "#") {
+			continue
+		}
+		id, fname, found := strings.Cut(ln, ",")
+		if !found {
+			return nil, &ParseError{Line: lineNr, Err: fmt.Errorf("%w: missing ','", ErrInvalidLine)}
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			return nil, &ParseError{Line: lineNr, Err: fmt.Errorf("%w: %w", ErrInvalidLine, err)}
+		}
+		res[n] = strings.TrimSpace(fname)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package palette_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/drs"
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLoadDRS(t *testing.T) {
+	pal, err := os.ReadFile("./testdata/50500_palette.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := testutil.DRSReader(t, "bina", map[drs.FileID][]byte{
+		50500: pal,
+		50501: []byte("not a palette"),
+	})
+
+	set := palette.NewSet()
+	if assert.NoError(t, set.LoadDRS(rd)) {
+		assert.Equal(t, []int{50500}, set.IDs())
+		assert.Equal(t, palette.Default, set.Lookup(50500))
+		assert.Equal(t, palette.Default, set.Lookup(1234), "fallback to the default palette")
+	}
+}
+
+func TestSetLoadConf(t *testing.T) {
+	jasc, err := palette.Marshal(palette.Default[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"palettes/palettes.conf":  {Data: []byte("// ID,file\r\n0,blue.pal\r\n\r\n50500, standard.pal\r\n")},
+		"palettes/blue.pal":       {Data: jasc},
+		"palettes/standard.pal":   {Data: jasc},
+		"other/50532_palette.txt": {Data: jasc},
+		"other/readme.txt":        {Data: []byte("ignored")},
+		"other/50500.slp":         {Data: []byte("not a palette")},
+	}
+
+	set := palette.NewSet()
+	if assert.NoError(t, set.LoadConf(fsys, "palettes/"+palette.ConfName)) {
+		assert.Equal(t, []int{0, 50500}, set.IDs())
+	}
+	if assert.NoError(t, set.LoadFS(fsys, "other")) {
+		pal, found := set.Get(50532)
+		assert.True(t, found)
+		assert.Len(t, pal, 4)
+	}
+}
+
+func TestSetZeroValue(t *testing.T) {
+	var set palette.Set
+	set.Add(1, palette.Default[:2])
+	assert.Equal(t, []int{1}, set.IDs())
+}
+
+func TestParseConfError(t *testing.T) {
+	_, err := palette.ParseConf(strings.NewReader("0,a.pal\nbroken\n"))
+	var perr *palette.ParseError
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, 2, perr.Line)
+	}
+}
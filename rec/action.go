@@ -0,0 +1,255 @@
+package rec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Action types of the command operations.
+const (
+	ActionInteract     = 0
+	ActionStop         = 1
+	ActionMove         = 3
+	ActionResign       = 11
+	ActionAITrain      = 100
+	ActionResearch     = 101
+	ActionBuild        = 102
+	ActionDelete       = 106
+	ActionAttackGround = 107
+	ActionTribute      = 108
+	ActionTrain        = 119
+)
+
+type (
+	// Action is a command of a player or a chat message.
+	Action interface {
+		// Info returns the time and player of the action.
+		Info() *Base
+	}
+
+	// Base is embedded in all actions.
+	Base struct {
+		Time   time.Duration // since the start of the game
+		Player int           // -1 if the command does not contain it
+	}
+
+	// Interact is a right click on a target: attack, gather, garrison, ...
+	Interact struct {
+		Base
+		Target int32 // object ID
+		X, Y   float32
+		Units  []int32
+	}
+
+	Move struct {
+		Base
+		X, Y  float32
+		Units []int32
+	}
+
+	Stop struct {
+		Base
+		Units []int32
+	}
+
+	AttackGround struct {
+		Base
+		X, Y  float32
+		Units []int32
+	}
+
+	// Train queues units in a building. The command does not contain the
+	// player.
+	Train struct {
+		Base
+		Building int32 // object ID
+		UnitType int   // unit ID of the DAT file
+		Count    int
+	}
+
+	Research struct {
+		Base
+		Building int32 // object ID
+		Tech     int   // tech ID of the DAT file
+	}
+
+	Build struct {
+		Base
+		BuildingType int // unit ID of the DAT file
+		X, Y         float32
+		Units        []int32 // the builders
+	}
+
+	Delete struct {
+		Base
+		Object int32
+	}
+
+	Tribute struct {
+		Base
+		Target   int
+		Resource int
+		Amount   float32
+		Fee      float32
+	}
+
+	Resign struct {
+		Base
+	}
+
+	Chat struct {
+		Base
+		Text string
+	}
+
+	// UnknownAction is a command which is not decoded.
+	UnknownAction struct {
+		Base
+		Type byte
+		Data []byte
+	}
+)
+
+func (b *Base) Info() *Base { return b }
+
+// payload reads the fields of a command.
+type payload struct {
+	rd  *bytes.Reader
+	err error
+}
+
+func (p *payload) read(v any) {
+	if p.err == nil {
+		p.err = binary.Read(p.rd, binary.LittleEndian, v)
+	}
+}
+
+func (p *payload) skip(n int) {
+	p.read(make([]byte, n))
+}
+
+func (p *payload) u8() int {
+	var v uint8
+	p.read(&v)
+	return int(v)
+}
+
+func (p *payload) u16() int {
+	var v uint16
+	p.read(&v)
+	return int(v)
+}
+
+func (p *payload) i32() int32 {
+	var v int32
+	p.read(&v)
+	return v
+}
+
+func (p *payload) f32() float32 {
+	var v float32
+	p.read(&v)
+	return v
+}
+
+// units reads n object IDs, 0xff means the current selection.
+func (p *payload) units(n int) []int32 {
+	if n == 0xff || p.err != nil {
+		return nil
+	}
+	if n*4 > p.rd.Len() {
+		p.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	res := make([]int32, n)
+	p.read(res)
+	return res
+}
+
+// decodeAction decodes the data of a command operation.
+func decodeAction(t time.Duration, data []byte) (Action, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: empty command", ErrInvalidOperation)
+	}
+	p := &payload{rd: bytes.NewReader(data[1:])}
+	c := Base{Time: t, Player: -1}
+	var a Action
+	switch data[0] {
+	case ActionInteract, ActionMove:
+		c.Player = p.u8()
+		p.skip(2)
+		target := p.i32()
+		n := p.u8()
+		p.skip(3)
+		x, y := p.f32(), p.f32()
+		units := p.units(n)
+		if data[0] == ActionMove {
+			a = &Move{Base: c, X: x, Y: y, Units: units}
+		} else {
+			a = &Interact{Base: c, Target: target, X: x, Y: y, Units: units}
+		}
+	case ActionStop:
+		a = &Stop{Base: c, Units: p.units(p.u8())}
+	case ActionAttackGround:
+		n := p.u8()
+		p.skip(2)
+		x, y := p.f32(), p.f32()
+		a = &AttackGround{Base: c, X: x, Y: y, Units: p.units(n)}
+	case ActionResign:
+		c.Player = p.u8()
+		a = &Resign{Base: c}
+	case ActionResearch:
+		p.skip(3)
+		building := p.i32()
+		c.Player = p.u16()
+		a = &Research{Base: c, Building: building, Tech: p.u16()}
+	case ActionTrain:
+		p.skip(3)
+		building := p.i32()
+		a = &Train{Base: c, Building: building, UnitType: p.u16(), Count: p.u16()}
+	case ActionAITrain:
+		p.skip(3)
+		building := p.i32()
+		c.Player = p.u16()
+		a = &Train{Base: c, Building: building, UnitType: p.u16(), Count: 1}
+	case ActionBuild:
+		n := p.u8()
+		c.Player = p.u8()
+		p.skip(1)
+		x, y := p.f32(), p.f32()
+		building := p.u16()
+		p.skip(2 + 4 + 4) // unknown and sprite
+		a = &Build{Base: c, BuildingType: building, X: x, Y: y, Units: p.units(n)}
+	case ActionDelete:
+		p.skip(3)
+		obj := p.i32()
+		c.Player = int(p.i32())
+		a = &Delete{Base: c, Object: obj}
+	case ActionTribute:
+		c.Player = p.u8()
+		target, resource := p.u8(), p.u8()
+		a = &Tribute{Base: c, Target: target, Resource: resource, Amount: p.f32(), Fee: p.f32()}
+	default:
+		return &UnknownAction{Base: c, Type: data[0], Data: data[1:]}, nil
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("%w: command %d: %w", ErrInvalidOperation, data[0], p.err)
+	}
+	return a, nil
+}
+
+// decodeChat decodes a chat message, the text starts with "@#" and the
+// player number.
+func decodeChat(t time.Duration, text []byte) *Chat {
+	text = bytes.TrimRight(text, "\x00")
+	c := &Chat{Base: Base{Time: t, Player: -1}}
+	if len(text) >= 3 && text[0] == '@' && text[1] == '#' && text[2] >= '0' && text[2] <= '9' {
+		c.Player = int(text[2] - '0')
+		text = text[3:]
+	}
+	c.Text = string(text)
+	return c
+}
@@ -0,0 +1,58 @@
+package rec
+
+import "gopkg.in/KlemensWinter/go-genie.v1/scenario"
+
+type (
+	// AI holds the compiled AI scripts of the computer players.
+	AI struct {
+		MaxStrings  uint16
+		StringCount uint16
+		Unknown     uint32
+		Strings     []scenario.String32 `bin:"len=StringCount"` // the symbols of the scripts
+		Unknown2    [6]byte
+		Scripts     [scenario.NumPlayers]AIScript
+		Unknown3    [104]byte
+		Timers      [scenario.NumPlayers][10]int32
+		SharedGoals [256]int32
+		Unknown4    [4096]byte
+	}
+
+	// AIScript holds the rules of the script of a player.
+	AIScript struct {
+		Unknown   uint32
+		MaxRules  uint16
+		RuleCount uint16
+		Unknown2  uint32
+		Rules     []AIRule `bin:"len=RuleCount"`
+	}
+
+	// AIRule is a rule of a script, its facts are followed by its actions.
+	AIRule struct {
+		Unknown   [12]byte
+		FactCount uint8
+		ItemCount uint8 // facts and actions
+		Unknown2  uint16
+		Items     [16]AIRuleItem
+	}
+
+	// AIRuleItem is a fact or an action of a rule.
+	AIRuleItem struct {
+		Type    uint32
+		ID      uint16
+		Unknown uint16
+		Params  [4]int32
+	}
+)
+
+// Facts returns the facts of the rule.
+func (r *AIRule) Facts() []AIRuleItem {
+	n := min(int(r.FactCount), len(r.Items))
+	return r.Items[:n]
+}
+
+// Actions returns the actions of the rule.
+func (r *AIRule) Actions() []AIRuleItem {
+	n := min(int(r.FactCount), len(r.Items))
+	m := min(max(int(r.ItemCount), n), len(r.Items))
+	return r.Items[n:m]
+}
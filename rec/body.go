@@ -0,0 +1,206 @@
+package rec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Operation types of the body.
+const (
+	OpCommand  = 1
+	OpSync     = 2
+	OpViewLock = 3
+	OpMeta     = 4 // chat messages and the game start
+)
+
+// Meta commands.
+const (
+	metaChat      = -1
+	metaGameStart = 500
+)
+
+const maxCommandLength = 1 << 16
+
+var ErrInvalidOperation = errors.New("rec: invalid operation")
+
+// Meta is the start of the body.
+type Meta struct {
+	LogVersion         uint32 // missing in AoK games
+	ChecksumInterval   uint32
+	Multiplayer        uint32
+	RecOwner           uint32
+	RevealMap          uint32
+	UseSequenceNumbers uint32
+	ChapterCount       uint32
+}
+
+// ActionReader reads the actions of the body one after another, without
+// keeping them in memory. It is used with Next rather than as a range-over-func
+// iterator because the module supports Go 1.21.
+type ActionReader struct {
+	Meta Meta
+
+	rd      *bufio.Reader
+	version Version
+	time    time.Duration
+	started bool
+	err     error
+}
+
+// Actions returns a reader for the actions of the game.
+func (r *Reader) Actions() *ActionReader {
+	return NewActionReader(io.NewSectionReader(r.rd, r.body, r.size-r.body), r.Version)
+}
+
+// NewActionReader returns a reader for the body of a recorded game.
+func NewActionReader(r io.Reader, version Version) *ActionReader {
+	return &ActionReader{rd: bufio.NewReader(r), version: version}
+}
+
+// Time returns the game time of the last operation.
+func (ar *ActionReader) Time() time.Duration {
+	return ar.time
+}
+
+func (ar *ActionReader) read(v any) error {
+	err := binary.Read(ar.rd, binary.LittleEndian, v)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (ar *ActionReader) skip(n int) error {
+	_, err := ar.rd.Discard(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (ar *ActionReader) readMeta() error {
+	m := &ar.Meta
+	fields := []*uint32{&m.LogVersion, &m.ChecksumInterval, &m.Multiplayer, &m.RecOwner, &m.RevealMap, &m.UseSequenceNumbers, &m.ChapterCount}
+	if ar.version == VersionAoK {
+		fields = fields[1:]
+	}
+	for _, f := range fields {
+		if err := ar.read(f); err != nil {
+			return fmt.Errorf("rec: meta: %w", err)
+		}
+	}
+	return nil
+}
+
+// Next returns the next action. It returns io.EOF at the end of the game.
+func (ar *ActionReader) Next() (Action, error) {
+	if ar.err != nil {
+		return nil, ar.err
+	}
+	a, err := ar.next()
+	if err != nil {
+		ar.err = err
+	}
+	return a, err
+}
+
+func (ar *ActionReader) next() (Action, error) {
+	if !ar.started {
+		ar.started = true
+		if err := ar.readMeta(); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		var op uint32
+		if err := binary.Read(ar.rd, binary.LittleEndian, &op); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("rec: %w", err)
+		}
+		a, err := ar.operation(op)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			return a, nil
+		}
+	}
+}
+
+// operation reads an operation, it returns nil for operations without an
+// action.
+func (ar *ActionReader) operation(op uint32) (Action, error) {
+	switch op {
+	case OpCommand:
+		var n uint32
+		if err := ar.read(&n); err != nil {
+			return nil, fmt.Errorf("rec: command: %w", err)
+		}
+		if n > maxCommandLength {
+			return nil, fmt.Errorf("%w: command of %d bytes", ErrInvalidOperation, n)
+		}
+		data := make([]byte, n)
+		if err := ar.read(data); err != nil {
+			return nil, fmt.Errorf("rec: command: %w", err)
+		}
+		if err := ar.skip(4); err != nil { // world time
+			return nil, fmt.Errorf("rec: command: %w", err)
+		}
+		return decodeAction(ar.time, data)
+
+	case OpSync:
+		var sync [2]uint32 // time increment in ms, checksum marker
+		if err := ar.read(&sync); err != nil {
+			return nil, fmt.Errorf("rec: sync: %w", err)
+		}
+		ar.time += time.Duration(sync[0]) * time.Millisecond
+		n := 12 // view
+		if sync[1] == 0 {
+			n += 28 // checksums
+		}
+		if err := ar.skip(n); err != nil {
+			return nil, fmt.Errorf("rec: sync: %w", err)
+		}
+		return nil, nil
+
+	case OpViewLock:
+		if err := ar.skip(12); err != nil {
+			return nil, fmt.Errorf("rec: view lock: %w", err)
+		}
+		return nil, nil
+
+	case OpMeta:
+		var cmd int32
+		if err := ar.read(&cmd); err != nil {
+			return nil, fmt.Errorf("rec: meta: %w", err)
+		}
+		switch cmd {
+		case metaChat:
+			var n uint32
+			if err := ar.read(&n); err != nil {
+				return nil, fmt.Errorf("rec: chat: %w", err)
+			}
+			if n > maxCommandLength {
+				return nil, fmt.Errorf("%w: chat of %d bytes", ErrInvalidOperation, n)
+			}
+			text := make([]byte, n)
+			if err := ar.read(text); err != nil {
+				return nil, fmt.Errorf("rec: chat: %w", err)
+			}
+			return decodeChat(ar.time, text), nil
+		case metaGameStart:
+			if err := ar.skip(20); err != nil {
+				return nil, fmt.Errorf("rec: game start: %w", err)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: meta command %d", ErrInvalidOperation, cmd)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrInvalidOperation, op)
+}
@@ -0,0 +1,169 @@
+package rec_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/rec"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The body of testdata/game.mgx: Alice moves two villagers, researches loom
+// and trains a villager, Bob builds a house, chats, attacks with the
+// villager and resigns after one minute.
+
+func TestActions(t *testing.T) {
+	r, err := rec.Open("./testdata/game.mgx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	var actions []rec.Action
+	ar := r.Actions()
+	for {
+		a, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		actions = append(actions, a)
+	}
+	assert.Equal(t, uint32(500), ar.Meta.ChecksumInterval)
+	assert.Equal(t, 60500*time.Millisecond, ar.Time())
+
+	ms := time.Millisecond
+	assert.Equal(t, []rec.Action{
+		&rec.Move{Base: rec.Base{Time: 500 * ms, Player: 1}, X: 10.5, Y: 12, Units: []int32{10, 11}},
+		&rec.Research{Base: rec.Base{Time: 500 * ms, Player: 1}, Building: 20, Tech: 22},
+		&rec.Train{Base: rec.Base{Time: 1500 * ms, Player: -1}, Building: 20, UnitType: 83, Count: 1},
+		&rec.Build{Base: rec.Base{Time: 1500 * ms, Player: 2}, BuildingType: 70, X: 20, Y: 21, Units: []int32{30}},
+		&rec.Chat{Base: rec.Base{Time: 1500 * ms, Player: 2}, Text: "Bob: gl hf"},
+		&rec.Interact{Base: rec.Base{Time: 60 * time.Second, Player: 2}, Target: 10, X: 10.5, Y: 12, Units: []int32{30}},
+		&rec.UnknownAction{Base: rec.Base{Time: 60 * time.Second, Player: -1}, Type: 0x42, Data: []byte{1, 2, 3}},
+		&rec.Resign{Base: rec.Base{Time: 60 * time.Second, Player: 2}},
+	}, actions)
+
+	_, err = ar.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSummarize(t *testing.T) {
+	r, err := rec.Open("./testdata/game.mgx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	s, err := rec.Summarize(r.Actions())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 60500*time.Millisecond, s.Duration)
+	if !assert.Len(t, s.Players, 2) {
+		return
+	}
+	alice := s.Player(1)
+	assert.Equal(t, 3, alice.Actions)
+	assert.Equal(t, []rec.Step{
+		{Time: 500 * time.Millisecond, Kind: rec.StepResearch, ID: 22, Count: 1},
+		{Time: 1500 * time.Millisecond, Kind: rec.StepTrain, ID: 83, Count: 1},
+	}, alice.BuildOrder)
+	assert.InDelta(t, 3/60.5*60, alice.APM(s.Duration), 1e-9)
+
+	bob := s.Player(2)
+	assert.Equal(t, 3, bob.Actions)
+	assert.Equal(t, 60*time.Second, bob.Resigned)
+	assert.Len(t, bob.BuildOrder, 1)
+	assert.Nil(t, s.Player(3))
+}
+
+// body builds the body of a recorded game.
+type body struct {
+	bytes.Buffer
+}
+
+func newBody() *body {
+	b := new(body)
+	binary.Write(b, binary.LittleEndian, [7]uint32{})
+	return b
+}
+
+func (b *body) command(fields ...any) {
+	var data bytes.Buffer
+	for _, f := range fields {
+		binary.Write(&data, binary.LittleEndian, f)
+	}
+	binary.Write(b, binary.LittleEndian, [2]uint32{rec.OpCommand, uint32(data.Len())})
+	b.Write(data.Bytes())
+	binary.Write(b, binary.LittleEndian, uint32(0)) // world time
+}
+
+func (b *body) sync(ms uint32) {
+	binary.Write(b, binary.LittleEndian, [3]uint32{rec.OpSync, ms, 1})
+	b.Write(make([]byte, 12))
+}
+
+func TestSummarizeOwners(t *testing.T) {
+	var pad [3]byte
+	b := newBody()
+	// trains before the owner of the building is known
+	b.command(uint8(rec.ActionTrain), pad, int32(20), uint16(83), uint16(1))
+	b.command(uint8(rec.ActionTrain), pad, int32(40), uint16(4), uint16(2))
+	b.sync(1000)
+	b.command(uint8(rec.ActionMove), uint8(1), [2]byte{}, int32(-1), uint8(1), pad, float32(5), float32(5), int32(10))
+	b.command(uint8(rec.ActionStop), uint8(1), int32(10))
+	b.command(uint8(rec.ActionAttackGround), uint8(1), [2]byte{}, float32(7), float32(7), int32(11))
+	b.command(uint8(rec.ActionResearch), pad, int32(20), uint16(1), uint16(22))
+	b.sync(1000)
+	b.command(uint8(rec.ActionMove), uint8(2), [2]byte{}, int32(-1), uint8(1), pad, float32(9), float32(9), int32(11))
+
+	s, err := rec.Summarize(rec.NewActionReader(b, rec.VersionAoC))
+	if !assert.NoError(t, err) || !assert.Len(t, s.Players, 2) {
+		return
+	}
+	// train, move, stop and research
+	alice := s.Player(1)
+	assert.Equal(t, 4, alice.Actions)
+	assert.Equal(t, []rec.Step{
+		{Time: 0, Kind: rec.StepTrain, ID: 83, Count: 1},
+		{Time: time.Second, Kind: rec.StepResearch, ID: 22, Count: 1},
+	}, alice.BuildOrder)
+
+	// the attack on the ground is resolved by the later move
+	bob := s.Player(2)
+	assert.Equal(t, 2, bob.Actions)
+	assert.Empty(t, bob.BuildOrder)
+
+	// nothing refers to building 40
+	assert.Equal(t, []rec.Step{{Time: 0, Kind: rec.StepTrain, ID: 4, Count: 2}}, s.Unowned)
+}
+
+func TestActionsErrors(t *testing.T) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, [7]uint32{})
+	binary.Write(&b, binary.LittleEndian, uint32(9))
+	_, err := rec.NewActionReader(&b, rec.VersionAoC).Next()
+	assert.ErrorIs(t, err, rec.ErrInvalidOperation)
+
+	b.Reset()
+	binary.Write(&b, binary.LittleEndian, [7]uint32{})
+	binary.Write(&b, binary.LittleEndian, [2]uint32{rec.OpCommand, 10})
+	b.Write([]byte{rec.ActionMove, 1})
+	_, err = rec.NewActionReader(&b, rec.VersionAoC).Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// more units than the command contains
+	var pad [3]byte
+	body := newBody()
+	body.command(uint8(rec.ActionMove), uint8(1), [2]byte{}, int32(-1), uint8(5), pad, float32(5), float32(5), int32(10))
+	_, err = rec.NewActionReader(body, rec.VersionAoC).Next()
+	assert.ErrorIs(t, err, rec.ErrInvalidOperation)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
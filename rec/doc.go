@@ -0,0 +1,57 @@
+// Read recorded games (.mgl, .mgx, .mgz, .aoe2record).
+//
+// A recorded game starts with the compressed header, followed by the body
+// with the operations of the game:
+//
+//	+-------------------+
+//	| HeaderLength      | including the length fields
+//	| NextPos           | missing in .mgl files
+//	| Header            | deflate compressed
+//	+-------------------+
+//	| Body              |
+//	+-------------------+
+//
+// The header is the saved game state at the start of the game:
+//
+//	+-------------------+
+//	| version           | e.g. "VER 9.4" and 11.76
+//	| AI                |
+//	| replay            | game speed, players, ...
+//	| map               | size, tiles, obstructions, visibility
+//	| players           | particles, player states and units
+//	| scenario          | see package scenario
+//	| game settings     | map type, difficulty, player names
+//	+-------------------+
+//
+// The header of AoK, AoC and UserPatch games is supported, including the AI
+// scripts of computer players. HD and DE headers differ in most sections,
+// NewReader returns ErrUnsupportedVersion for them; their version can be
+// detected with DetectVersion.
+//
+// The player state is a serialization of all game objects whose layout
+// depends on the exact game version. Only the start of the state of each
+// player is decoded (see PlayerState), the initial units and their owners
+// are not. The state of gaia follows the map, the others are located by
+// their attribute count and name. The scenario section behind the last
+// state is located by its version number and only accepted if its
+// separators are valid and its players have a state.
+//
+// The layouts of the AI section and the player states follow mgz
+// (https://github.com/happyleavesaoc/aoc-mgz).
+//
+// The body is a stream of operations: commands of the players, chat
+// messages and syncs, which advance the game time. ActionReader decodes
+// them one by one:
+//
+//	ar := r.Actions()
+//	for {
+//		a, err := ar.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+//
+// Summarize uses it to count the actions and collect the build order of
+// each player.
+package rec
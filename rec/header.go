@@ -0,0 +1,490 @@
+package rec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+)
+
+// MaxPlayers is the number of player slots, including gaia.
+const MaxPlayers = 9
+
+// Indices of PlayerState.Attributes.
+const (
+	AttributeFood  = 0
+	AttributeWood  = 1
+	AttributeStone = 2
+	AttributeGold  = 3
+)
+
+// Limits of the header, beyond them the header is invalid.
+const (
+	maxMapSize    = 1024 // tiles per side
+	maxZones      = 1 << 10
+	maxParticles  = 1 << 16
+	maxAttributes = 1 << 10
+	maxNameLength = 256 // including the NUL byte
+)
+
+// Player types of the game settings.
+const (
+	PlayerTypeAbsent     = 0
+	PlayerTypeClosed     = 1
+	PlayerTypeHuman      = 2
+	PlayerTypeEliminated = 3
+	PlayerTypeComputer   = 4
+	PlayerTypeCyborg     = 5
+	PlayerTypeSpectator  = 6
+)
+
+// scenarioVersions are searched to find the scenario section.
+var scenarioVersions = []float32{1.22, 1.21, 1.20, 1.19, 1.18}
+
+type (
+	// Replay holds the state of the game engine.
+	Replay struct {
+		OldTime              uint32
+		WorldTime            uint32
+		OldWorldTime         uint32
+		GameSpeedID          uint32
+		WorldTimeDelta       uint32
+		GameSpeed            float32
+		TempPause            uint8
+		NextObjectID         uint32
+		NextReusableObjectID int32
+		RandomSeed           uint32
+		RandomSeed2          uint32
+		RecPlayer            uint16 // the player who recorded the game
+		PlayerCount          uint8  // including gaia
+		InstantBuild         uint8
+		CheatsEnabled        uint8
+		GameMode             uint16
+		Campaign             uint32
+		CampaignPlayer       uint32
+		CampaignScenario     uint32
+		KingCampaign         uint32
+		KingCampaignPlayer   uint8
+		KingCampaignScenario uint8
+		PlayerTurn           uint32
+		PlayerTimeDelta      [MaxPlayers]uint32
+	}
+
+	// Scenario is the part of a scenario stored in a recorded game. For
+	// random maps it holds the player civilizations.
+	Scenario struct {
+		Data       scenario.DataHeader
+		Messages   scenario.Messages
+		Cinematics scenario.Cinematics
+		Bitmap     scenario.Bitmap
+		Players    scenario.Players
+		Victory    scenario.GlobalVictory
+		Diplomacy  scenario.Diplomacy
+		Disables   scenario.Disables `bin:"if=$Version>=118"`
+	}
+
+	PlayerSetting struct {
+		Index uint32
+		Type  uint32
+		Name  scenario.String32
+	}
+
+	// GameSettings are the settings of the lobby.
+	GameSettings struct {
+		MapID      uint32
+		Difficulty uint32
+		LockTeams  uint32
+		Players    [MaxPlayers]PlayerSetting // gaia first
+	}
+
+	// PlayerState is the start of the saved state of a player. The rest of
+	// the state, including the units of the player, is not decoded.
+	PlayerState struct {
+		Type          uint8
+		Diplomacy     []uint8 // towards each player, gaia first
+		Stances       [MaxPlayers]int32
+		AlliedLOS     uint32
+		AlliedVictory uint8
+		Name          string
+		Attributes    []float32 // resources and counters, see the Attribute constants
+	}
+
+	Header struct {
+		GameVersion [8]byte // e.g. "VER 9.4"
+		SaveVersion float32 // e.g. 11.76
+		Version     Version `bin:"-"`
+
+		IncludeAI uint32
+		AI        *AI `bin:"if=IncludeAI!=0"`
+		Replay    Replay
+
+		Map        scenario.Map `bin:"-"`
+		AllVisible bool         `bin:"-"`
+		FogOfWar   bool         `bin:"-"`
+
+		PlayerStates []PlayerState `bin:"-"` // gaia first
+
+		Scenario Scenario     `bin:"-"`
+		Settings GameSettings `bin:"-"`
+	}
+
+	// Player is a player of the game.
+	Player struct {
+		Number int // 1 to 8
+		Name   string
+		Type   uint32 // see the PlayerType constants
+		Civ    int
+	}
+)
+
+// GameVersionString returns the game version, e.g. "VER 9.4".
+func (h *Header) GameVersionString() string {
+	if i := bytes.IndexByte(h.GameVersion[:], 0); i >= 0 {
+		return string(h.GameVersion[:i])
+	}
+	return string(h.GameVersion[:])
+}
+
+// Players returns the human and computer players.
+func (h *Header) Players() []Player {
+	var res []Player
+	for i := 1; i < MaxPlayers; i++ {
+		s := &h.Settings.Players[i]
+		if s.Type != PlayerTypeHuman && s.Type != PlayerTypeComputer {
+			continue
+		}
+		res = append(res, Player{
+			Number: i,
+			Name:   s.Name.String(),
+			Type:   s.Type,
+			Civ:    int(h.Scenario.Data.PlayerInfos[i-1].Civ),
+		})
+	}
+	return res
+}
+
+// decodeHeader decodes the uncompressed header.
+func decodeHeader(data []byte) (*Header, error) {
+	var h Header
+	rd := bytes.NewReader(data)
+	d := codec.NewDecoder(rd, codec.Config{})
+	if err := d.Decode(&h.GameVersion); err != nil {
+		return nil, fmt.Errorf("rec: %w", err)
+	}
+	if err := d.Decode(&h.SaveVersion); err != nil {
+		return nil, fmt.Errorf("rec: %w", err)
+	}
+	h.Version = detectVersion(h.GameVersionString(), h.SaveVersion)
+	switch h.Version {
+	case VersionAoK, VersionAoC, VersionUserPatch:
+	default:
+		return nil, fmt.Errorf("%w: %q %.2f", ErrUnsupportedVersion, h.GameVersionString(), h.SaveVersion)
+	}
+	if err := d.DecodeFrom(&h, 2); err != nil {
+		return nil, fmt.Errorf("rec: %w", err)
+	}
+	if h.Replay.PlayerCount == 0 || h.Replay.PlayerCount > MaxPlayers {
+		return nil, fmt.Errorf("%w: %d players", ErrInvalidHeader, h.Replay.PlayerCount)
+	}
+
+	if err := h.decodeMap(rd); err != nil {
+		return nil, fmt.Errorf("rec: map: %w", err)
+	}
+	pos, err := h.decodePlayers(data, len(data)-rd.Len())
+	if err != nil {
+		return nil, err
+	}
+
+	err = ErrScenarioNotFound
+	for _, c := range findScenario(data[pos:]) {
+		if err = h.decodeScenario(data[pos+c.offset:], c.version); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// skip advances rd by n bytes.
+func skip(rd *bytes.Reader, n int64) error {
+	if n > int64(rd.Len()) {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := rd.Seek(n, io.SeekCurrent)
+	return err
+}
+
+// decodeMap reads the map size, zones, tiles, obstructions and visibility.
+// Only the tiles are kept.
+func (h *Header) decodeMap(rd *bytes.Reader) error {
+	le := binary.LittleEndian
+	var size [3]uint32 // width, height, zone count
+	if err := binary.Read(rd, le, &size); err != nil {
+		return err
+	}
+	if size[0] > maxMapSize || size[1] > maxMapSize || size[2] > maxZones {
+		return fmt.Errorf("%w: %dx%d, %d zones", ErrInvalidHeader, size[0], size[1], size[2])
+	}
+	w, ht := int64(size[0]), int64(size[1])
+	if 2*w*ht > int64(rd.Len()) {
+		return fmt.Errorf("%w: %dx%d tiles in %d bytes", ErrInvalidHeader, w, ht, rd.Len())
+	}
+	for i := uint32(0); i < size[2]; i++ {
+		if err := skip(rd, 1275+w*ht); err != nil {
+			return err
+		}
+		var floats uint32
+		if err := binary.Read(rd, le, &floats); err != nil {
+			return err
+		}
+		if err := skip(rd, int64(floats)*4+4); err != nil {
+			return err
+		}
+	}
+	var flags [2]uint8
+	if err := binary.Read(rd, le, &flags); err != nil {
+		return err
+	}
+	h.AllVisible, h.FogOfWar = flags[0] != 0, flags[1] != 0
+
+	tiles := make([]byte, 2*w*ht)
+	if _, err := io.ReadFull(rd, tiles); err != nil {
+		return err
+	}
+	h.Map = scenario.Map{Width: uint32(w), Height: uint32(ht), Rows: make([]scenario.TileRow, ht)}
+	for y := range h.Map.Rows {
+		row := make([]scenario.Tile, w)
+		for x := range row {
+			i := 2 * (int64(y)*w + int64(x))
+			row[x] = scenario.Tile{Terrain: tiles[i], Elevation: tiles[i+1]}
+		}
+		h.Map.Rows[y].Tiles = row
+	}
+
+	// obstructions
+	var count uint32
+	if err := binary.Read(rd, le, &count); err != nil {
+		return err
+	}
+	if err := skip(rd, 4+int64(count)*4); err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		var n uint32
+		if err := binary.Read(rd, le, &n); err != nil {
+			return err
+		}
+		if err := skip(rd, int64(n)*8); err != nil {
+			return err
+		}
+	}
+
+	// visibility
+	if err := binary.Read(rd, le, size[:2]); err != nil {
+		return err
+	}
+	if size[0] > maxMapSize || size[1] > maxMapSize {
+		return fmt.Errorf("%w: visibility %dx%d", ErrInvalidHeader, size[0], size[1])
+	}
+	return skip(rd, int64(size[0])*int64(size[1])*4)
+}
+
+// decodePlayers reads the particles and the start of the player states at
+// data[pos:] and returns the offset behind the start of the last state.
+// The state of gaia follows the particles; as the end of a state is not
+// known, the next one is searched by its attribute count, which is the same
+// for all players and framed by marker bytes.
+func (h *Header) decodePlayers(data []byte, pos int) (int, error) {
+	rd := bytes.NewReader(data[pos:])
+	var initial [2]uint32 // restore time, particle count
+	if err := binary.Read(rd, binary.LittleEndian, &initial); err != nil {
+		return 0, fmt.Errorf("rec: particles: %w", err)
+	}
+	if initial[1] > maxParticles {
+		return 0, fmt.Errorf("%w: %d particles", ErrInvalidHeader, initial[1])
+	}
+	if err := skip(rd, int64(initial[1])*27+4); err != nil {
+		return 0, fmt.Errorf("rec: particles: %w", err)
+	}
+	pos = len(data) - rd.Len()
+
+	players := int(h.Replay.PlayerCount)
+	h.PlayerStates = make([]PlayerState, players)
+	p, next, err := decodePlayer(data, pos, players)
+	if err != nil {
+		return 0, fmt.Errorf("%w: gaia: %w", ErrPlayerNotFound, err)
+	}
+	h.PlayerStates[0] = p
+	for i := 1; i < players; i++ {
+		if h.PlayerStates[i], next, err = findPlayer(data, next, players, len(p.Attributes)); err != nil {
+			return 0, fmt.Errorf("%w: player %d", err, i)
+		}
+	}
+	return next, nil
+}
+
+// playerPrefix is the size of a player state in front of the name length.
+func playerPrefix(players int) int {
+	return 2 + players + 4*MaxPlayers + 4 + 1
+}
+
+// decodePlayer reads the start of a player state at data[pos:] and returns
+// the offset behind it.
+func decodePlayer(data []byte, pos, players int) (PlayerState, int, error) {
+	rd := bytes.NewReader(data[pos:])
+	le := binary.LittleEndian
+	var (
+		p       PlayerState
+		head    [2]uint8 // type, unknown
+		nameLen uint16
+	)
+	p.Diplomacy = make([]uint8, players)
+	for _, v := range []any{&head, p.Diplomacy, &p.Stances, &p.AlliedLOS, &p.AlliedVictory, &nameLen} {
+		if err := binary.Read(rd, le, v); err != nil {
+			return p, 0, err
+		}
+	}
+	p.Type = head[0]
+	if nameLen == 0 || nameLen > maxNameLength {
+		return p, 0, fmt.Errorf("%w: name length %d", ErrInvalidHeader, nameLen)
+	}
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(rd, name); err != nil {
+		return p, 0, err
+	}
+	p.Name = cstring(name)
+
+	var attrs struct {
+		Marker  uint8 // 0x16
+		Count   uint32
+		Marker2 uint8 // 0x21
+	}
+	if err := binary.Read(rd, le, &attrs); err != nil {
+		return p, 0, err
+	}
+	if attrs.Marker != 0x16 || attrs.Marker2 != 0x21 || attrs.Count > maxAttributes {
+		return p, 0, fmt.Errorf("%w: invalid attributes of %q", ErrInvalidHeader, p.Name)
+	}
+	p.Attributes = make([]float32, attrs.Count)
+	if err := binary.Read(rd, le, p.Attributes); err != nil {
+		return p, 0, err
+	}
+	var end uint8
+	if err := binary.Read(rd, le, &end); err != nil {
+		return p, 0, err
+	}
+	if end != 0x0b {
+		return p, 0, fmt.Errorf("%w: invalid end of %q", ErrInvalidHeader, p.Name)
+	}
+	return p, len(data) - rd.Len(), nil
+}
+
+// findPlayer returns the first player state behind data[pos:] with the
+// given number of attributes and the offset behind its start.
+func findPlayer(data []byte, pos, players, attributes int) (PlayerState, int, error) {
+	var marker [6]byte
+	marker[0] = 0x16
+	binary.LittleEndian.PutUint32(marker[1:], uint32(attributes))
+	marker[5] = 0x21
+	prefix := playerPrefix(players)
+	for i := pos; ; {
+		j := bytes.Index(data[i:], marker[:])
+		if j < 0 {
+			return PlayerState{}, 0, ErrPlayerNotFound
+		}
+		at := i + j
+		i = at + 1
+		// the marker follows the NUL terminated name and its length
+		for n := 1; n <= maxNameLength && at-n-2-prefix >= pos; n++ {
+			if data[at-1] != 0 || int(binary.LittleEndian.Uint16(data[at-n-2:])) != n {
+				continue
+			}
+			if p, next, err := decodePlayer(data, at-n-2-prefix, players); err == nil {
+				return p, next, nil
+			}
+		}
+	}
+}
+
+// candidate is a possible start of the scenario section.
+type candidate struct {
+	offset  int
+	version int // multiplied by 100
+}
+
+// findScenario returns the possible offsets of the scenario section. The
+// section starts with the next unit ID followed by the version, which may
+// also occur in the player state.
+func findScenario(data []byte) []candidate {
+	var res []candidate
+	for _, v := range scenarioVersions {
+		var marker [4]byte
+		binary.LittleEndian.PutUint32(marker[:], math.Float32bits(v))
+		for i := 0; ; {
+			j := bytes.Index(data[i:], marker[:])
+			if j < 0 {
+				break
+			}
+			if i+j >= 4 {
+				res = append(res, candidate{i + j - 4, int(math.Round(float64(v) * 100))})
+			}
+			i += j + 1
+		}
+	}
+	return res
+}
+
+// decodeScenario reads the scenario section and the game settings at the
+// start of data. The section is only accepted if its separators are valid
+// and the active players of the game settings have player states.
+func (h *Header) decodeScenario(data []byte, version int) error {
+	d := codec.NewDecoder(bytes.NewReader(data), codec.Config{Vars: map[string]int{"Version": version}})
+	var sc Scenario
+	if err := d.Decode(&sc); err != nil {
+		return fmt.Errorf("rec: scenario: %w", err)
+	}
+	for _, sep := range []uint32{sc.Players.Separator, sc.Victory.Separator, sc.Diplomacy.Separator} {
+		if sep != scenario.Separator {
+			return fmt.Errorf("%w: invalid separator %#x", ErrScenarioNotFound, sep)
+		}
+	}
+	var settings GameSettings
+	if err := d.Decode(&settings); err != nil {
+		return fmt.Errorf("rec: game settings: %w", err)
+	}
+	for i := 1; i < MaxPlayers; i++ {
+		s := &settings.Players[i]
+		if s.Type != PlayerTypeHuman && s.Type != PlayerTypeComputer {
+			continue
+		}
+		if !h.hasPlayerState(s.Name.String()) {
+			return fmt.Errorf("%w: no player state for %q", ErrScenarioNotFound, s.Name.String())
+		}
+	}
+	h.Scenario, h.Settings = sc, settings
+	return nil
+}
+
+// hasPlayerState returns true if a player state has the given name.
+func (h *Header) hasPlayerState(name string) bool {
+	for i := range h.PlayerStates {
+		if h.PlayerStates[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cstring returns b up to the first NUL byte.
+func cstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
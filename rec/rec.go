@@ -0,0 +1,123 @@
+package rec
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const maxHeaderLength = 1 << 26 // of the compressed header
+
+var (
+	ErrUnsupportedVersion = errors.New("rec: unsupported version")
+	ErrInvalidHeader      = errors.New("rec: invalid header")
+	ErrPlayerNotFound     = errors.New("rec: player state not found")
+	ErrScenarioNotFound   = errors.New("rec: scenario section not found")
+)
+
+type Reader struct {
+	*Header
+
+	rd   io.ReaderAt // the underlying reader
+	size int64
+	body int64 // offset of the body
+}
+
+func Open(filename string) (*Reader, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	r, err := NewReader(fh, fi.Size())
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewReader reads the header of a recorded game.
+func NewReader(rd io.ReaderAt, size int64) (*Reader, error) {
+	data, n, err := readHeader(rd, size)
+	if err != nil {
+		return nil, err
+	}
+	h, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{Header: h, rd: rd, size: size, body: n}, nil
+}
+
+// DetectVersion returns the game a recorded game was made with. Unlike
+// NewReader it supports all versions.
+func DetectVersion(rd io.ReaderAt, size int64) (Version, error) {
+	data, _, err := readHeader(rd, size)
+	if err != nil {
+		return VersionUnknown, err
+	}
+	var v struct {
+		GameVersion [8]byte
+		SaveVersion float32
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &v); err != nil {
+		return VersionUnknown, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+	h := Header{GameVersion: v.GameVersion}
+	return detectVersion(h.GameVersionString(), v.SaveVersion), nil
+}
+
+// readHeader returns the uncompressed header and the offset of the body.
+func readHeader(rd io.ReaderAt, size int64) ([]byte, int64, error) {
+	sr := io.NewSectionReader(rd, 0, size)
+	var length uint32
+	if err := binary.Read(sr, binary.LittleEndian, &length); err != nil {
+		return nil, 0, fmt.Errorf("rec: failed to read header length: %w", err)
+	}
+	n := int64(length)
+	if n < 8 || n > size || n > maxHeaderLength {
+		return nil, 0, fmt.Errorf("%w: length %d", ErrInvalidHeader, n)
+	}
+	compressed := make([]byte, n)
+	if _, err := io.ReadFull(io.NewSectionReader(rd, 0, n), compressed); err != nil {
+		return nil, 0, fmt.Errorf("rec: failed to read header: %w", err)
+	}
+
+	// .mgl files have no NextPos
+	data, err := inflate(compressed[8:])
+	if err != nil {
+		if data, err = inflate(compressed[4:]); err != nil {
+			return nil, 0, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+	}
+	return data, n, nil
+}
+
+// inflate decompresses a header, which has to start with a version string.
+func inflate(b []byte) ([]byte, error) {
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("VER ")) {
+		return nil, errors.New("missing version")
+	}
+	return data, nil
+}
+
+// Close closes the underlying reader if it implements io.Closer, otherwise it's a noop.
+func (r *Reader) Close() error {
+	if c, ok := r.rd.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package rec_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/rec"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/game.mgx is a synthetic AoC 1.0c game on a 4x3 map: Alice (civ 5)
+// against Bob (civ 6).
+
+func TestOpen(t *testing.T) {
+	r, err := rec.Open("./testdata/game.mgx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+	assert.Equal(t, "VER 9.4", r.GameVersionString())
+	assert.Equal(t, float32(11.76), r.SaveVersion)
+	assert.Equal(t, rec.VersionAoC, r.Version)
+	assert.Equal(t, float32(1.7), r.Replay.GameSpeed)
+	assert.Equal(t, uint16(1), r.Replay.RecPlayer)
+	assert.Equal(t, uint8(3), r.Replay.PlayerCount)
+
+	assert.False(t, r.AllVisible)
+	assert.True(t, r.FogOfWar)
+	assert.Equal(t, uint32(4), r.Map.Width)
+	if tile := r.Map.Tile(3, 2); assert.NotNil(t, tile) {
+		assert.Equal(t, uint8(11), tile.Terrain)
+		assert.Equal(t, uint8(2), tile.Elevation)
+	}
+
+	assert.Equal(t, uint32(9), r.Settings.MapID)
+	assert.Equal(t, []rec.Player{
+		{Number: 1, Name: "Alice", Type: rec.PlayerTypeHuman, Civ: 5},
+		{Number: 2, Name: "Bob", Type: rec.PlayerTypeHuman, Civ: 6},
+	}, r.Players())
+
+	if assert.Len(t, r.PlayerStates, 3) {
+		assert.Equal(t, "Gaia", r.PlayerStates[0].Name)
+		alice := r.PlayerStates[1]
+		assert.Equal(t, "Alice", alice.Name)
+		assert.Equal(t, []uint8{4, 1, 3}, alice.Diplomacy)
+		assert.Equal(t, float32(200), alice.Attributes[rec.AttributeWood])
+		assert.Equal(t, float32(100), alice.Attributes[rec.AttributeStone])
+		assert.Equal(t, "Bob", r.PlayerStates[2].Name)
+	}
+	assert.Nil(t, r.AI)
+}
+
+// header returns a recorded game with the given uncompressed header.
+func header(data []byte) []byte {
+	var z bytes.Buffer
+	zw, _ := flate.NewWriter(&z, flate.BestSpeed)
+	zw.Write(data)
+	zw.Close()
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, uint32(z.Len()+8))
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	b.Write(z.Bytes())
+	return b.Bytes()
+}
+
+func TestDetectVersion(t *testing.T) {
+	for _, tc := range []struct {
+		game string
+		save float32
+		want rec.Version
+	}{
+		{"VER 9.3", 11.76, rec.VersionAoK},
+		{"VER 9.4", 11.76, rec.VersionAoC},
+		{"VER 9.4", 12.34, rec.VersionHD},
+		{"VER 9.4", 13.34, rec.VersionDE},
+		{"VER 9.D", 12.36, rec.VersionUserPatch},
+		{"VER 9.1", 10.0, rec.VersionUnknown},
+	} {
+		var h bytes.Buffer
+		h.WriteString(tc.game + "\x00")
+		binary.Write(&h, binary.LittleEndian, tc.save)
+		data := header(h.Bytes())
+		v, err := rec.DetectVersion(bytes.NewReader(data), int64(len(data)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.want, v, tc.game)
+		}
+	}
+
+	var h bytes.Buffer
+	h.WriteString("VER 9.4\x00")
+	binary.Write(&h, binary.LittleEndian, float32(13.34))
+	h.Write(make([]byte, 100))
+	data := header(h.Bytes())
+	_, err := rec.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.ErrorIs(t, err, rec.ErrUnsupportedVersion)
+}
+
+func TestErrors(t *testing.T) {
+	data, err := os.ReadFile("./testdata/game.mgx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = rec.NewReader(bytes.NewReader(data), 100)
+	assert.ErrorIs(t, err, rec.ErrInvalidHeader)
+
+	bad := bytes.Clone(data)
+	bad[20] ^= 0xff
+	_, err = rec.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.Error(t, err)
+}
+
+// inflateHeader returns the uncompressed header of a recorded game.
+func inflateHeader(t *testing.T, data []byte) []byte {
+	t.Helper()
+	n := binary.LittleEndian.Uint32(data)
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(data[8:n])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestScenarioMarkerInPlayerState(t *testing.T) {
+	data, err := os.ReadFile("./testdata/game.mgx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := inflateHeader(t, data)
+
+	// the scenario version preceded by the next unit ID
+	var marker [4]byte
+	binary.LittleEndian.PutUint32(marker[:], math.Float32bits(1.22))
+	pos := bytes.Index(raw, marker[:]) - 4
+	if pos < 0 {
+		t.Fatal("scenario section not found")
+	}
+	// a player state containing a copy of the section without separators
+	fake := bytes.ReplaceAll(raw[pos:], []byte{0x9d, 0xff, 0xff, 0xff}, make([]byte, 4))
+	fake = bytes.ReplaceAll(fake, []byte("Alice"), []byte("Eve!!"))
+	raw = append(raw[:pos:pos], append(fake, raw[pos:]...)...)
+
+	rec2 := header(raw)
+	r, err := rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []rec.Player{
+			{Number: 1, Name: "Alice", Type: rec.PlayerTypeHuman, Civ: 5},
+			{Number: 2, Name: "Bob", Type: rec.PlayerTypeHuman, Civ: 6},
+		}, r.Players())
+	}
+
+	// without the real section
+	rec2 = header(raw[:pos+len(fake)])
+	_, err = rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	assert.Error(t, err)
+
+	// a copy with valid separators, but players without a state
+	raw = inflateHeader(t, data)
+	fake = bytes.ReplaceAll(raw[pos:], []byte("Alice"), []byte("Eve!!"))
+	raw = append(raw[:pos:pos], append(fake, raw[pos:]...)...)
+	rec2 = header(raw)
+	r, err = rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Alice", r.Players()[0].Name)
+	}
+}
+
+// mapOffset is the offset of the map size in the header of
+// testdata/game.mgx.
+const mapOffset = 122
+
+func TestMapBounds(t *testing.T) {
+	data, err := os.ReadFile("./testdata/game.mgx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range [][2]uint32{
+		{0, 0xffffffff},
+		{0xffffffff, 0xffffffff},
+		{1000, 1000},
+	} {
+		raw := inflateHeader(t, data)
+		binary.LittleEndian.PutUint32(raw[mapOffset:], size[0])
+		binary.LittleEndian.PutUint32(raw[mapOffset+4:], size[1])
+		rec2 := header(raw)
+		_, err := rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+		assert.ErrorIs(t, err, rec.ErrInvalidHeader, "%dx%d", size[0], size[1])
+	}
+}
+
+func TestPlayerNotFound(t *testing.T) {
+	data, err := os.ReadFile("./testdata/game.mgx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := inflateHeader(t, data)
+	i := bytes.Index(raw, []byte("Bob\x00\x16"))
+	if i < 0 {
+		t.Fatal("player state of Bob not found")
+	}
+	raw[i+4] = 0
+	rec2 := header(raw)
+	_, err = rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	assert.ErrorIs(t, err, rec.ErrPlayerNotFound)
+}
+
+// aiSection returns an AI section with two strings and a rule of player 1.
+func aiSection() []byte {
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&b, le, [2]uint16{2, 2}) // max strings, strings
+	binary.Write(&b, le, uint32(0))
+	for _, s := range []string{"food-amount", "train"} {
+		binary.Write(&b, le, uint32(len(s)))
+		b.WriteString(s)
+	}
+	b.Write(make([]byte, 6))
+	for i := 0; i < 8; i++ {
+		rules := uint16(0)
+		if i == 0 {
+			rules = 1
+		}
+		binary.Write(&b, le, uint32(0))
+		binary.Write(&b, le, [2]uint16{rules, rules})
+		binary.Write(&b, le, uint32(0))
+		if rules == 0 {
+			continue
+		}
+		b.Write(make([]byte, 12))
+		b.Write([]byte{1, 2, 0, 0})                // one fact, one action
+		binary.Write(&b, le, [6]uint32{1, 0, 100}) // type, ID, params
+		binary.Write(&b, le, [6]uint32{2, 1, 83})
+		b.Write(make([]byte, 14*24))
+	}
+	b.Write(make([]byte, 104+8*10*4+256*4+4096))
+	return b.Bytes()
+}
+
+func TestAI(t *testing.T) {
+	data, err := os.ReadFile("./testdata/game.mgx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := inflateHeader(t, data)
+	// IncludeAI follows the version and the save version
+	withAI := append(bytes.Clone(raw[:12]), 1, 0, 0, 0)
+	withAI = append(withAI, aiSection()...)
+	withAI = append(withAI, raw[16:]...)
+
+	rec2 := header(withAI)
+	r, err := rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	if !assert.NoError(t, err) || !assert.NotNil(t, r.AI) {
+		return
+	}
+	assert.Equal(t, "train", r.AI.Strings[1].String())
+	if assert.Len(t, r.AI.Scripts[0].Rules, 1) {
+		rule := &r.AI.Scripts[0].Rules[0]
+		assert.Equal(t, []rec.AIRuleItem{{Type: 1, Params: [4]int32{100}}}, rule.Facts())
+		assert.Equal(t, []rec.AIRuleItem{{Type: 2, ID: 1, Params: [4]int32{83}}}, rule.Actions())
+	}
+	assert.Len(t, r.Players(), 2)
+
+	rec2 = header(withAI[:len(withAI)-len(raw)+100])
+	_, err = rec.NewReader(bytes.NewReader(rec2), int64(len(rec2)))
+	assert.Error(t, err)
+}
+
+// TestGameFiles reads the headers of recorded games, see
+// testutil.GameFiles. Games of unsupported versions are skipped.
+func TestGameFiles(t *testing.T) {
+	for _, filename := range testutil.GameFiles(t, "*.mgl", "*.mgx", "*.mgz") {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			r, err := rec.Open(filename)
+			if errors.Is(err, rec.ErrUnsupportedVersion) {
+				t.Skip(err)
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer r.Close()
+			assert.NotEmpty(t, r.Players())
+			assert.NotZero(t, r.Map.Width)
+			_, err = rec.Summarize(r.Actions())
+			assert.NoError(t, err)
+		})
+	}
+}
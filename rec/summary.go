@@ -0,0 +1,189 @@
+package rec
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// Kinds of build order steps.
+const (
+	StepTrain = iota
+	StepResearch
+	StepBuild
+)
+
+type (
+	// Step is an entry of a build order.
+	Step struct {
+		Time  time.Duration
+		Kind  int // StepTrain, StepResearch or StepBuild
+		ID    int // unit or tech ID of the DAT file
+		Count int
+	}
+
+	PlayerSummary struct {
+		Player     int
+		Actions    int // without chat messages
+		Resigned   time.Duration
+		BuildOrder []Step
+	}
+
+	Summary struct {
+		Duration time.Duration
+		Players  []*PlayerSummary // sorted by player number
+
+		// Unowned are the build order steps of buildings whose owner is
+		// unknown, see Summarize.
+		Unowned []Step
+	}
+
+	// pending is an action whose player is not known yet.
+	pending struct {
+		seq      int   // index of the action
+		step     *Step // nil if the action is not a build order step
+		resolved bool
+	}
+)
+
+// APM returns the actions per minute of the player during the game.
+func (ps *PlayerSummary) APM(d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(ps.Actions) / d.Minutes()
+}
+
+// Player returns the summary of a player or nil.
+func (s *Summary) Player(player int) *PlayerSummary {
+	for _, ps := range s.Players {
+		if ps.Player == player {
+			return ps
+		}
+	}
+	return nil
+}
+
+// Summarize reads all actions and counts them per player.
+//
+// Some commands do not contain the player: trains, stops and attacks on the
+// ground. Their player is the owner of the building or units, which is taken
+// from other commands of the same objects, e.g. researches or moves, even if
+// these come later. The initial units are not read from the header (see the
+// package documentation), so trains of buildings no other command refers to
+// are collected in Summary.Unowned, other commands of unknown objects are
+// not counted. Only actions whose player is still unknown are kept.
+func Summarize(ar *ActionReader) (*Summary, error) {
+	players := make(map[int]*PlayerSummary)
+	owners := make(map[int32]int) // object ID -> player
+	waiting := make(map[int32][]*pending)
+	steps := make(map[int]*Step) // unresolved build order steps by index
+	get := func(player int) *PlayerSummary {
+		ps, found := players[player]
+		if !found {
+			ps = &PlayerSummary{Player: player}
+			players[player] = ps
+		}
+		return ps
+	}
+	record := func(player int, step *Step) {
+		ps := get(player)
+		ps.Actions++
+		if step != nil {
+			ps.BuildOrder = append(ps.BuildOrder, *step)
+		}
+	}
+	own := func(player int, objects ...int32) {
+		for _, id := range objects {
+			owners[id] = player
+			for _, p := range waiting[id] {
+				if !p.resolved {
+					p.resolved = true
+					record(player, p.step)
+					delete(steps, p.seq)
+				}
+			}
+			delete(waiting, id)
+		}
+	}
+
+	for seq := 0; ; seq++ {
+		a, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		info := a.Info()
+		player := info.Player
+		var objects []int32 // owned by the player
+		var step *Step
+		switch a := a.(type) {
+		case *Chat:
+			continue
+		case *Interact:
+			objects = a.Units
+		case *Move:
+			objects = a.Units
+		case *Stop:
+			objects = a.Units
+		case *AttackGround:
+			objects = a.Units
+		case *Delete:
+			objects = []int32{a.Object}
+		case *Train:
+			objects = []int32{a.Building}
+			step = &Step{info.Time, StepTrain, a.UnitType, a.Count}
+		case *Research:
+			objects = []int32{a.Building}
+			step = &Step{info.Time, StepResearch, a.Tech, 1}
+		case *Build:
+			objects = a.Units
+			step = &Step{info.Time, StepBuild, a.BuildingType, 1}
+		}
+		for _, id := range objects {
+			if owner, found := owners[id]; found && player < 0 {
+				player = owner
+			}
+		}
+		if player < 0 {
+			if len(objects) > 0 {
+				p := &pending{seq: seq, step: step}
+				if step != nil {
+					steps[seq] = step
+				}
+				for _, id := range objects {
+					waiting[id] = append(waiting[id], p)
+				}
+			}
+			continue
+		}
+		own(player, objects...)
+		record(player, step)
+		if _, ok := a.(*Resign); ok {
+			get(player).Resigned = info.Time
+		}
+	}
+
+	s := &Summary{Duration: ar.Time()}
+	for _, ps := range players {
+		// actions resolved later are appended out of order
+		sort.SliceStable(ps.BuildOrder, func(i, j int) bool {
+			return ps.BuildOrder[i].Time < ps.BuildOrder[j].Time
+		})
+		s.Players = append(s.Players, ps)
+	}
+	sort.Slice(s.Players, func(i, j int) bool {
+		return s.Players[i].Player < s.Players[j].Player
+	})
+	seqs := make([]int, 0, len(steps))
+	for seq := range steps {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	for _, seq := range seqs {
+		s.Unowned = append(s.Unowned, *steps[seq])
+	}
+	return s, nil
+}
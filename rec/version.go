@@ -0,0 +1,63 @@
+package rec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Version is the game a recorded game was made with.
+type Version int
+
+const (
+	VersionUnknown   Version = iota
+	VersionAoK               // The Age of Kings
+	VersionAoC               // The Conquerors
+	VersionUserPatch         // The Conquerors with UserPatch
+	VersionHD                // HD Edition
+	VersionDE                // Definitive Edition
+)
+
+var versionNames = [...]string{
+	VersionAoK:       "AoK",
+	VersionAoC:       "AoC",
+	VersionUserPatch: "UserPatch",
+	VersionHD:        "HD",
+	VersionDE:        "DE",
+}
+
+// Save versions of the last AoC patch and the first DE release.
+const (
+	saveVersionAoC = 11.76
+	saveVersionDE  = 12.97
+)
+
+// detectVersion returns the game of a recorded game from the game version,
+// e.g. "VER 9.4", and the save version.
+func detectVersion(game string, save float32) Version {
+	switch {
+	case game == "VER 9.3":
+		return VersionAoK
+	case game == "VER 9.4" && save <= saveVersionAoC+0.001:
+		return VersionAoC
+	case game == "VER 9.4" && save >= saveVersionDE-0.001:
+		return VersionDE
+	case game == "VER 9.4":
+		return VersionHD
+	case strings.HasPrefix(game, "VER 9.") && len(game) == 7 && game[6] >= '8':
+		// 9.8 to 9.F
+		return VersionUserPatch
+	}
+	return VersionUnknown
+}
+
+// IsValid returns true for the known versions.
+func (v Version) IsValid() bool {
+	return v >= VersionAoK && v <= VersionDE
+}
+
+func (v Version) String() string {
+	if !v.IsValid() {
+		return fmt.Sprintf("Version(%d)", int(v))
+	}
+	return versionNames[v]
+}
@@ -0,0 +1,350 @@
+package scenario
+
+import (
+	"fmt"
+	"image"
+)
+
+// Defaults of New.
+const (
+	DefaultVersion        = "1.21"
+	DefaultTriggerVersion = 1.6
+)
+
+// New returns an empty AoC scenario with a map of the given size filled with
+// terrain. All players are inactive, see SetPlayer.
+func New(width, height int, terrain uint8) *Scenario {
+	s := &Scenario{
+		Header: Header{SaveVersion: 2},
+		Data: DataHeader{
+			NextUnitID: 1,
+			Version:    1.22,
+			Unknown1:   1,
+			Unknown3:   -1,
+		},
+		Victory: GlobalVictory{Separator: Separator, Conquest: 1},
+		Map:     NewMap(width, height, terrain),
+		Units: Units{
+			Players: make([]UnitList, NumPlayers+1),
+		},
+		Settings: PlayerSettings{PlayerCount: NumPlayers + 1},
+		Triggers: Triggers{Version: DefaultTriggerVersion},
+	}
+	copy(s.Version[:], DefaultVersion)
+	s.Players.Separator = Separator
+	s.Diplomacy.Separator = Separator
+
+	for i := 0; i < MaxPlayers; i++ {
+		s.Data.PlayerInfos[i] = PlayerInfo{Civ: 1, Unused: 4}
+		s.Players.Resources[i] = Resources{Gold: 100, Wood: 200, Food: 200, Stone: 100}
+		s.Disables.StartingAge[i] = -1
+		for j := range s.Diplomacy.Stances[i] {
+			s.Diplomacy.Stances[i][j] = StanceEnemy
+		}
+		s.Diplomacy.Stances[i][i] = StanceAllied
+	}
+	for i := range s.Units.Resources {
+		s.Units.Resources[i] = StartResources{Food: 200, Wood: 200, Gold: 100, Stone: 100, PopLimit: 75}
+	}
+	for i := range s.Settings.Players {
+		p := &s.Settings.Players[i]
+		p.Diplomacy = make([]uint8, NumPlayers+1)
+		p.Diplomacy2 = make([]uint32, NumPlayers+1)
+		for j := range p.Diplomacy2 {
+			p.Diplomacy2[j] = StanceEnemy
+		}
+		p.Diplomacy2[i+1] = StanceAllied
+		p.Color = uint32(i)
+		p.VictoryVersion = 2
+		p.Unknown = -1
+	}
+	return s
+}
+
+// NewMap returns a map of the given size filled with terrain.
+func NewMap(width, height int, terrain uint8) Map {
+	m := Map{
+		Separator: Separator,
+		Width:     uint32(width),
+		Height:    uint32(height),
+		Rows:      make([]TileRow, height),
+	}
+	for y := range m.Rows {
+		m.Rows[y].Tiles = make([]Tile, width)
+	}
+	m.Fill(image.Rect(0, 0, width, height), terrain)
+	return m
+}
+
+// Fill sets the terrain of the tiles in r, the rectangle is clipped to the
+// map.
+func (m *Map) Fill(r image.Rectangle, terrain uint8) {
+	for y := max(r.Min.Y, 0); y < min(r.Max.Y, len(m.Rows)); y++ {
+		row := m.Rows[y].Tiles
+		for x := max(r.Min.X, 0); x < min(r.Max.X, len(row)); x++ {
+			row[x].Terrain = terrain
+		}
+	}
+}
+
+// SetPlayer activates player (1 to 8) with the given civilization.
+func (s *Scenario) SetPlayer(player int, name string, civ int, human bool) error {
+	if player < 1 || player > NumPlayers {
+		return fmt.Errorf("%w: %d", ErrInvalidPlayer, player)
+	}
+	info := &s.Data.PlayerInfos[player-1]
+	if info.Active == 0 {
+		s.Header.PlayerCount++
+	}
+	info.Active = 1
+	info.Civ = uint32(civ)
+	info.Human = 0
+	if human {
+		info.Human = 1
+	}
+	s.Data.PlayerNames[player-1] = [256]byte{}
+	copy(s.Data.PlayerNames[player-1][:255], name)
+	return nil
+}
+
+// AddUnit places a unit of the DAT unit type at x, y and returns its ID.
+// Player 0 is gaia, 1 to 8 are the players.
+func (s *Scenario) AddUnit(player int, unitType uint16, x, y float32) (uint32, error) {
+	if player < 0 || player > NumPlayers {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidPlayer, player)
+	}
+	for len(s.Units.Players) <= player {
+		s.Units.Players = append(s.Units.Players, UnitList{})
+	}
+	id := s.Data.NextUnitID
+	s.Data.NextUnitID++
+	list := &s.Units.Players[player]
+	list.Units = append(list.Units, Unit{
+		X:            x,
+		Y:            y,
+		ID:           id,
+		Type:         unitType,
+		Status:       2,
+		GarrisonedIn: -1,
+	})
+	return id, nil
+}
+
+// NewTrigger returns an enabled trigger without conditions and effects.
+func NewTrigger(name string) *Trigger {
+	return &Trigger{
+		Enabled: 1,
+		Name:    String32{Value: name + "\x00"},
+	}
+}
+
+// If adds conditions to t, all of them have to be met.
+func (t *Trigger) If(conds ...Condition) *Trigger {
+	for _, c := range conds {
+		t.ConditionOrder = append(t.ConditionOrder, int32(len(t.Conditions)))
+		t.Conditions = append(t.Conditions, c)
+	}
+	return t
+}
+
+// Then adds effects to t.
+func (t *Trigger) Then(effects ...Effect) *Trigger {
+	for _, e := range effects {
+		t.EffectOrder = append(t.EffectOrder, int32(len(t.Effects)))
+		t.Effects = append(t.Effects, e)
+	}
+	return t
+}
+
+// Loop makes t fire every time its conditions are met.
+func (t *Trigger) Loop() *Trigger {
+	t.Looping = 1
+	return t
+}
+
+// Disable disables t, it can be activated with ActivateTrigger.
+func (t *Trigger) Disable() *Trigger {
+	t.Enabled = 0
+	return t
+}
+
+// AddTrigger adds a copy of t and returns its ID.
+func (s *Scenario) AddTrigger(t *Trigger) int {
+	id := len(s.Triggers.Triggers)
+	s.Triggers.Triggers = append(s.Triggers.Triggers, *t)
+	s.Triggers.DisplayOrder = append(s.Triggers.DisplayOrder, int32(id))
+	return id
+}
+
+// NewCondition returns a condition of type t with all fields unset.
+func NewCondition(t ConditionType) Condition {
+	c := Condition{Type: t, Fields: make([]int32, NumConditionFields)}
+	for i := range c.Fields {
+		c.Fields[i] = -1
+	}
+	return c
+}
+
+// NewEffect returns an effect of type t with all fields unset.
+func NewEffect(t EffectType) Effect {
+	e := Effect{Type: t, Fields: make([]int32, NumEffectFields)}
+	for i := range e.Fields {
+		e.Fields[i] = -1
+	}
+	e.Fields[EffectFieldSelectedCount] = 0
+	return e
+}
+
+func (c Condition) set(field, v int) Condition {
+	c.Fields[field] = int32(v)
+	return c
+}
+
+func (c Condition) area(r image.Rectangle) Condition {
+	return c.set(ConditionFieldAreaX1, r.Min.X).
+		set(ConditionFieldAreaY1, r.Min.Y).
+		set(ConditionFieldAreaX2, r.Max.X-1).
+		set(ConditionFieldAreaY2, r.Max.Y-1)
+}
+
+func (e Effect) set(field, v int) Effect {
+	e.Fields[field] = int32(v)
+	return e
+}
+
+func (e Effect) text(s string) Effect {
+	e.Text = String32{Value: s + "\x00"}
+	return e
+}
+
+func (e Effect) units(ids []uint32) Effect {
+	e.SelectedUnits = make([]int32, len(ids))
+	for i, id := range ids {
+		e.SelectedUnits[i] = int32(id)
+	}
+	return e.set(EffectFieldSelectedCount, len(ids))
+}
+
+// Timer is met after the given number of seconds.
+func Timer(seconds int) Condition {
+	return NewCondition(ConditionTimer).set(ConditionFieldTimer, seconds)
+}
+
+// OwnObjects is met if player owns at least amount units of the type.
+func OwnObjects(player, unitType, amount int) Condition {
+	return NewCondition(ConditionOwnObjects).
+		set(ConditionFieldPlayer, player).
+		set(ConditionFieldUnitType, unitType).
+		set(ConditionFieldAmount, amount)
+}
+
+// ObjectsInArea is met if player has at least amount units of the type in
+// the area, unitType -1 matches all units.
+func ObjectsInArea(player, unitType, amount int, area image.Rectangle) Condition {
+	return NewCondition(ConditionObjectsInArea).
+		set(ConditionFieldPlayer, player).
+		set(ConditionFieldUnitType, unitType).
+		set(ConditionFieldAmount, amount).
+		area(area)
+}
+
+// DestroyObject is met when the unit is destroyed.
+func DestroyObject(unitID uint32) Condition {
+	return NewCondition(ConditionDestroyObject).set(ConditionFieldUnitObject, int(unitID))
+}
+
+// Researched is met when player has researched the technology.
+func Researched(player, tech int) Condition {
+	return NewCondition(ConditionResearchTechnology).
+		set(ConditionFieldPlayer, player).
+		set(ConditionFieldTechnology, tech)
+}
+
+// PlayerDefeated is met when player is defeated.
+func PlayerDefeated(player int) Condition {
+	return NewCondition(ConditionPlayerDefeated).set(ConditionFieldPlayer, player)
+}
+
+// SendChat shows text in the chat of player.
+func SendChat(player int, text string) Effect {
+	return NewEffect(EffectSendChat).set(EffectFieldPlayerSource, player).text(text)
+}
+
+// DisplayInstructions shows text in the instructions panel.
+func DisplayInstructions(player int, text string, seconds int) Effect {
+	return NewEffect(EffectDisplayInstructions).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldDisplayTime, seconds).
+		set(EffectFieldInstructionPanel, 0).
+		text(text)
+}
+
+// CreateObject creates a unit of the type for player at x, y.
+func CreateObject(player, unitType, x, y int) Effect {
+	return NewEffect(EffectCreateObject).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldUnitType, unitType).
+		set(EffectFieldLocationX, x).
+		set(EffectFieldLocationY, y)
+}
+
+// TaskObject moves the units to x, y.
+func TaskObject(player int, units []uint32, x, y int) Effect {
+	return NewEffect(EffectTaskObject).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldLocationX, x).
+		set(EffectFieldLocationY, y).
+		units(units)
+}
+
+// KillObject kills the units.
+func KillObject(player int, units []uint32) Effect {
+	return NewEffect(EffectKillObject).set(EffectFieldPlayerSource, player).units(units)
+}
+
+// ChangeOwnership gives the units of player to target.
+func ChangeOwnership(player, target int, units []uint32) Effect {
+	return NewEffect(EffectChangeOwnership).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldPlayerTarget, target).
+		units(units)
+}
+
+// ChangeDiplomacy sets the stance of player towards target.
+func ChangeDiplomacy(player, target, stance int) Effect {
+	return NewEffect(EffectChangeDiplomacy).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldPlayerTarget, target).
+		set(EffectFieldDiplomacy, stance)
+}
+
+// Research researches the technology for player.
+func Research(player, tech int) Effect {
+	return NewEffect(EffectResearchTechnology).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldTechnology, tech)
+}
+
+// SendTribute gives amount of the resource from player to target.
+func SendTribute(player, target, resource, amount int) Effect {
+	return NewEffect(EffectSendTribute).
+		set(EffectFieldPlayerSource, player).
+		set(EffectFieldPlayerTarget, target).
+		set(EffectFieldResource, resource).
+		set(EffectFieldAmount, amount)
+}
+
+// ActivateTrigger enables the trigger with the ID returned by AddTrigger.
+func ActivateTrigger(id int) Effect {
+	return NewEffect(EffectActivateTrigger).set(EffectFieldTriggerID, id)
+}
+
+// DeactivateTrigger disables the trigger with the ID returned by AddTrigger.
+func DeactivateTrigger(id int) Effect {
+	return NewEffect(EffectDeactivateTrigger).set(EffectFieldTriggerID, id)
+}
+
+// DeclareVictory ends the game with player as winner.
+func DeclareVictory(player int) Effect {
+	return NewEffect(EffectDeclareVictory).set(EffectFieldPlayerSource, player)
+}
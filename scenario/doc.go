@@ -0,0 +1,48 @@
+// Read and write scenario files (.scn, .scx).
+//
+// A scenario starts with an uncompressed header, the rest of the file is
+// compressed with deflate:
+//
+//	+-------------------+
+//	| Version [4]byte   | e.g. "1.21"
+//	| HeaderLength      |
+//	| Header            |
+//	+-------------------+
+//	| data header       | deflate compressed
+//	| messages          |
+//	| cinematics        |
+//	| AI and resources  |
+//	| victory           |
+//	| diplomacy         |
+//	| disables          |
+//	| map               |
+//	| units             |
+//	| player settings   |
+//	| triggers          |
+//	| included files    |
+//	+-------------------+
+//
+// The versions 1.10 to 1.29 (AoE, AoK, AoC and HD) are supported. DE
+// scenarios (1.30 and later) differ from HD in almost every section and are
+// not supported, ErrUnsupportedVersion is returned for them.
+//
+// Strings are stored with a length prefix, see String16 and String32.
+// Messages and player names may reference language strings (see package
+// lang) instead of containing the text.
+//
+// New returns an empty AoC scenario, units and triggers are added with
+// AddUnit and AddTrigger:
+//
+//	s := scenario.New(120, 120, 0)
+//	if err := s.SetPlayer(1, "Player 1", 1, true); err != nil {
+//		return err
+//	}
+//	id, err := s.AddUnit(1, 83, 10.5, 10.5)
+//	if err != nil {
+//		return err
+//	}
+//	s.AddTrigger(scenario.NewTrigger("Move").
+//		If(scenario.Timer(30)).
+//		Then(scenario.TaskObject(1, []uint32{id}, 20, 20)))
+//	err = scenario.Create("test.scx", s)
+package scenario
@@ -0,0 +1,58 @@
+package scenario
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+)
+
+var ErrLengthMismatch = codec.ErrLengthMismatch
+
+// Encode writes s, the body is compressed with deflate. Count fields and
+// string lengths are updated to match the slices and strings before they
+// are written.
+func Encode(w io.Writer, s *Scenario) error {
+	v, err := parseVersion(s.Version)
+	if err != nil {
+		return err
+	}
+
+	var head bytes.Buffer
+	if err := codec.NewEncoder(&head, config(v)).Encode(&s.Header); err != nil {
+		return fmt.Errorf("scenario: header: %w", err)
+	}
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(head.Len()))
+	for _, b := range [][]byte{s.Version[:], size[:], head.Bytes()} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	zw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := codec.NewEncoder(zw, config(v)).EncodeFrom(s, 2); err != nil {
+		return fmt.Errorf("scenario: %w", err)
+	}
+	return zw.Close()
+}
+
+// Create writes s to the file filename, see Encode.
+func Create(filename string, s *Scenario) error {
+	fh, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := Encode(fh, s); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
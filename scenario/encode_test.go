@@ -0,0 +1,157 @@
+package scenario_test
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	s, err := scenario.Open("./testdata/hastings.scx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	if !assert.NoError(t, scenario.Encode(&buf, s)) {
+		return
+	}
+	s2, err := scenario.Decode(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, s, s2)
+	}
+}
+
+// TestRoundTripGameFiles reads and writes the scenarios of the game, see
+// testutil.GameFiles.
+func TestRoundTripGameFiles(t *testing.T) {
+	for _, filename := range testutil.GameFiles(t, "*.scn", "*.scx", "*.aoe2scenario") {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			version, _, err := scenario.DecodeHeader(bytes.NewReader(data))
+			if string(version[:]) >= "1.30" {
+				// DE scenarios are not supported
+				assert.ErrorIs(t, err, scenario.ErrUnsupportedVersion)
+				return
+			}
+			s, err := scenario.Decode(bytes.NewReader(data))
+			if !assert.NoError(t, err) {
+				return
+			}
+			var buf bytes.Buffer
+			if !assert.NoError(t, scenario.Encode(&buf, s)) {
+				return
+			}
+			s2, err := scenario.Decode(&buf)
+			if assert.NoError(t, err) {
+				assert.Equal(t, s, s2)
+			}
+		})
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	s := scenario.New(16, 12, 0)
+	s.Map.Fill(image.Rect(4, 4, 8, 6), 1)
+	assert.NoError(t, s.SetPlayer(1, "Alice", 5, true))
+	assert.NoError(t, s.SetPlayer(2, "Bob", 6, false))
+	addUnit := func(player int, unitType uint16, x, y float32) uint32 {
+		t.Helper()
+		id, err := s.AddUnit(player, unitType, x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	castle := addUnit(1, 82, 5.5, 5.5)
+	archers := []uint32{
+		addUnit(1, 4, 6.5, 5.5),
+		addUnit(1, 4, 7.5, 5.5),
+	}
+	addUnit(0, 349, 1.5, 1.5)
+	for _, player := range []int{-1, scenario.NumPlayers + 1} {
+		_, err := s.AddUnit(player, 4, 0, 0)
+		assert.ErrorIs(t, err, scenario.ErrInvalidPlayer)
+	}
+	for _, player := range []int{0, scenario.NumPlayers + 1} {
+		assert.ErrorIs(t, s.SetPlayer(player, "Gaia", 1, false), scenario.ErrInvalidPlayer)
+	}
+	assert.Equal(t, uint32(2), s.Header.PlayerCount)
+	assert.Len(t, s.Units.Players, scenario.NumPlayers+1)
+
+	victory := s.AddTrigger(scenario.NewTrigger("Victory").
+		Disable().
+		Then(scenario.DeclareVictory(1)))
+	s.AddTrigger(scenario.NewTrigger("Attack").
+		If(scenario.Timer(60), scenario.OwnObjects(1, 4, 2)).
+		Then(
+			scenario.SendChat(1, "Charge!"),
+			scenario.TaskObject(1, archers, 12, 10),
+		))
+	s.AddTrigger(scenario.NewTrigger("Defended").
+		If(scenario.ObjectsInArea(2, -1, 0, image.Rect(10, 8, 16, 12)), scenario.Timer(600)).
+		Then(scenario.ActivateTrigger(victory)))
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, scenario.Encode(&buf, s)) {
+		return
+	}
+	s2, err := scenario.Decode(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1.21", s2.VersionString())
+	assert.Equal(t, uint32(2), s2.Header.PlayerCount)
+	assert.Equal(t, "Bob", s2.PlayerName(1, nil))
+	assert.Equal(t, uint32(6), s2.Data.PlayerInfos[1].Civ)
+	assert.Equal(t, uint32(5), s2.Data.NextUnitID)
+	assert.Equal(t, uint8(1), s2.Map.Tile(7, 5).Terrain)
+	assert.Equal(t, uint8(0), s2.Map.Tile(8, 5).Terrain)
+	if u := s2.Units.Unit(castle); assert.NotNil(t, u) {
+		assert.Equal(t, uint16(82), u.Type)
+	}
+	assert.Len(t, s2.Units.Units(0), 1)
+	assert.Len(t, s2.Units.Units(1), 3)
+
+	assert.Equal(t, []int32{0, 1, 2}, s2.Triggers.DisplayOrder)
+	if !assert.Len(t, s2.Triggers.Triggers, 3) {
+		return
+	}
+	assert.Equal(t, uint32(0), s2.Triggers.Triggers[0].Enabled)
+	attack := s2.Triggers.Triggers[1]
+	assert.Equal(t, "Attack", attack.Name.String())
+	if assert.Len(t, attack.Conditions, 2) {
+		assert.Equal(t, scenario.ConditionTimer, attack.Conditions[0].Type)
+		assert.Equal(t, int32(60), attack.Conditions[0].Field(scenario.ConditionFieldTimer))
+	}
+	if assert.Len(t, attack.Effects, 2) {
+		assert.Equal(t, "Charge!", attack.Effects[0].Text.String())
+		task := attack.Effects[1]
+		assert.Equal(t, scenario.EffectTaskObject, task.Type)
+		assert.Equal(t, []int32{2, 3}, task.SelectedUnits)
+		assert.Equal(t, int32(2), task.Field(scenario.EffectFieldSelectedCount))
+	}
+	area := s2.Triggers.Triggers[2].Conditions[0]
+	assert.Equal(t, int32(15), area.Field(scenario.ConditionFieldAreaX2))
+	assert.Equal(t, int32(victory), s2.Triggers.Triggers[2].Effects[0].Field(scenario.EffectFieldTriggerID))
+}
+
+func TestEncodeErrors(t *testing.T) {
+	s := scenario.New(4, 4, 0)
+	s.Map.Rows[1].Tiles = s.Map.Rows[1].Tiles[:2]
+	assert.ErrorIs(t, scenario.Encode(io.Discard, s), scenario.ErrLengthMismatch)
+
+	s = scenario.New(4, 4, 0)
+	copy(s.Version[:], "1.47")
+	assert.ErrorIs(t, scenario.Encode(io.Discard, s), scenario.ErrUnsupportedVersion)
+}
@@ -0,0 +1,31 @@
+package scenario
+
+type (
+	Tile struct {
+		Terrain   uint8
+		Elevation uint8
+		Unused    uint8
+	}
+
+	TileRow struct {
+		Tiles []Tile `bin:"len=$MapWidth"`
+	}
+
+	Map struct {
+		Separator uint32
+		CameraY   int32  `bin:"if=$Version>=119"` // of player 1
+		CameraX   int32  `bin:"if=$Version>=119"`
+		AIMapType int32  `bin:"if=$Version>=121"`
+		Width     uint32 `bin:"var=MapWidth"`
+		Height    uint32
+		Rows      []TileRow `bin:"len=Height"`
+	}
+)
+
+// Tile returns the tile at x, y or nil if it is outside of the map.
+func (m *Map) Tile(x, y int) *Tile {
+	if y < 0 || y >= len(m.Rows) || x < 0 || x >= len(m.Rows[y].Tiles) {
+		return nil
+	}
+	return &m.Rows[y].Tiles[x]
+}
@@ -0,0 +1,68 @@
+package scenario
+
+// MessageKind selects one of the messages of a scenario.
+type MessageKind int
+
+const (
+	MessageInstructions MessageKind = iota
+	MessageHints
+	MessageVictory
+	MessageLoss
+	MessageHistory
+	MessageScouts // since 1.22
+)
+
+type (
+	// Messages are shown in the objectives screen. The string IDs
+	// reference language strings, see Scenario.Message.
+	Messages struct {
+		InstructionsID int32 `bin:"if=$Version>=118"`
+		HintsID        int32 `bin:"if=$Version>=118"`
+		VictoryID      int32 `bin:"if=$Version>=118"`
+		LossID         int32 `bin:"if=$Version>=118"`
+		HistoryID      int32 `bin:"if=$Version>=118"`
+		ScoutsID       int32 `bin:"if=$Version>=122"`
+
+		Instructions String16
+		Hints        String16
+		Victory      String16
+		Loss         String16
+		History      String16
+		Scouts       String16 `bin:"if=$Version>=122"`
+	}
+
+	// Cinematics are the names of the videos and the background image.
+	Cinematics struct {
+		Pregame    String16
+		Victory    String16
+		Loss       String16
+		Background String16
+	}
+
+	// Bitmap is the image shown in the instructions.
+	Bitmap struct {
+		Included    uint32
+		Width       uint32
+		Height      int32
+		Orientation int16
+		Info        *BitmapInfo `bin:"if=Included!=0"`
+	}
+
+	// BitmapInfo is a BITMAPINFOHEADER followed by the palette and the
+	// pixels.
+	BitmapInfo struct {
+		Size          uint32
+		Width         int32
+		Height        int32
+		Planes        uint16
+		BitCount      uint16
+		Compression   uint32
+		SizeImage     uint32
+		XPelsPerMeter int32
+		YPelsPerMeter int32
+		ClrUsed       uint32
+		ClrImportant  uint32
+		Colors        [256]uint32
+		Pixels        []byte `bin:"len=SizeImage"`
+	}
+)
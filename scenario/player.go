@@ -0,0 +1,138 @@
+package scenario
+
+const (
+	NumDisabledTechs     = 30
+	NumDisabledUnits     = 30
+	NumDisabledBuildings = 20
+)
+
+// Diplomatic stances.
+const (
+	StanceAllied  = 0
+	StanceNeutral = 1
+	StanceEnemy   = 3
+)
+
+type (
+	PlayerInfo struct {
+		Active uint32
+		Human  uint32
+		Civ    uint32
+		Unused uint32 // 4
+	}
+
+	// DataHeader is the first section of the compressed body.
+	DataHeader struct {
+		NextUnitID uint32
+		Version    float32
+
+		PlayerNames         [MaxPlayers][256]byte
+		PlayerNameStringIDs [MaxPlayers]int32 `bin:"if=$Version>=118"`
+		PlayerInfos         [MaxPlayers]PlayerInfo
+
+		Unknown1 uint32 // 1
+		Unknown2 uint8
+		Unknown3 float32 // -1
+
+		FileName String16
+	}
+
+	AIFile struct {
+		Unknown1 uint32
+		Unknown2 uint32
+		Script   String32
+	}
+
+	// Resources are the starting resources of a player.
+	Resources struct {
+		Gold   uint32
+		Wood   uint32
+		Food   uint32
+		Stone  uint32
+		OreX   uint32
+		Unused uint32
+		Color  uint32 `bin:"if=$Version>=124"`
+	}
+
+	// Players holds the AI and the starting resources of the players.
+	Players struct {
+		Unknown   [MaxPlayers]String16
+		AINames   [MaxPlayers]String16
+		AIFiles   [MaxPlayers]AIFile
+		AITypes   [MaxPlayers]uint8
+		Separator uint32
+		Resources [MaxPlayers]Resources
+	}
+
+	// GlobalVictory holds the victory conditions of the scenario.
+	GlobalVictory struct {
+		Separator   uint32
+		Conquest    uint32
+		Ruins       uint32
+		Relics      uint32
+		Discoveries uint32
+		Explored    uint32 // percentage
+		Gold        uint32
+		AllCustom   uint32
+		Mode        uint32
+		Score       uint32
+		Time        uint32 // in years
+	}
+
+	Diplomacy struct {
+		Stances             [MaxPlayers][MaxPlayers]uint32
+		IndividualVictories [MaxPlayers][720]byte // unused
+		Separator           uint32
+		AlliedVictory       [MaxPlayers]uint32
+	}
+
+	// Disables are the techs, units and buildings disabled per player.
+	Disables struct {
+		TechCount     [MaxPlayers]uint32
+		Techs         [MaxPlayers][NumDisabledTechs]int32
+		UnitCount     [MaxPlayers]uint32
+		Units         [MaxPlayers][NumDisabledUnits]int32
+		BuildingCount [MaxPlayers]uint32
+		Buildings     [MaxPlayers][NumDisabledBuildings]int32
+		Unused1       uint32
+		Unused2       uint32
+		FullTechTree  uint32
+		StartingAge   [MaxPlayers]int32 `bin:"if=$Version>=119"`
+	}
+
+	// PlayerSetting holds the camera, color and diplomacy of a player.
+	PlayerSetting struct {
+		Name           String16
+		CameraX        float32
+		CameraY        float32
+		UnknownX       int16
+		UnknownY       int16
+		AlliedVictory  uint8
+		DiplomacyCount uint16
+		Diplomacy      []uint8  `bin:"len=DiplomacyCount"`
+		Diplomacy2     []uint32 `bin:"len=DiplomacyCount"`
+		Color          uint32
+		VictoryVersion float32
+		UnknownCount   uint16
+		Unknown8       [8]byte    `bin:"if=VictoryVersion==200"`
+		Unknown44      [][44]byte `bin:"len=UnknownCount"`
+		Unknown7       [7]byte
+		Unknown        int32 // -1
+	}
+
+	PlayerSettings struct {
+		PlayerCount uint32 // including gaia
+		Players     [NumPlayers]PlayerSetting
+	}
+)
+
+// Disabled returns the disabled techs, units and buildings of a player.
+func (d *Disables) Disabled(player int) (techs, units, buildings []int32) {
+	if player < 0 || player >= MaxPlayers {
+		return nil, nil, nil
+	}
+	techs = d.Techs[player][:min(int(d.TechCount[player]), NumDisabledTechs)]
+	units = d.Units[player][:min(int(d.UnitCount[player]), NumDisabledUnits)]
+	buildings = d.Buildings[player][:min(int(d.BuildingCount[player]), NumDisabledBuildings)]
+	return techs, units, buildings
+}
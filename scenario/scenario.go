@@ -0,0 +1,239 @@
+package scenario
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/codec"
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+)
+
+const (
+	MaxPlayers = 16 // player slots stored in the file
+	NumPlayers = 8  // players without gaia
+
+	// Separator is stored between some of the sections.
+	Separator = 0xffffff9d
+)
+
+// Supported versions, multiplied by 100.
+const (
+	minVersion = 110
+	maxVersion = 129 // DE scenarios (1.30 and later) are not supported
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("scenario: unsupported version")
+	ErrInvalidHeader      = errors.New("scenario: invalid header")
+	ErrInvalidPlayer      = errors.New("scenario: invalid player")
+)
+
+type (
+	// String16 is a string with a 16 bit length prefix.
+	String16 struct {
+		Length uint16
+		Value  string `bin:"len=Length"`
+	}
+
+	// String32 is a string with a 32 bit length prefix.
+	String32 struct {
+		Length uint32
+		Value  string `bin:"len=Length"`
+	}
+
+	// Header is the uncompressed header of a scenario.
+	Header struct {
+		SaveVersion  int32  // 2 for AoK and AoC, 3 for HD
+		Timestamp    uint32 // of the last save, as UNIX time
+		Instructions String32
+		Unused       uint32
+		PlayerCount  uint32
+
+		Unknown      uint32   `bin:"if=SaveVersion>=3"` // 1000
+		GameEdition  uint32   `bin:"if=SaveVersion>=3"`
+		DatasetCount uint32   `bin:"if=SaveVersion>=3"`
+		Datasets     []uint32 `bin:"len=DatasetCount,if=SaveVersion>=3"`
+	}
+
+	// Scenario is a decoded scenario. The sections after the header are
+	// compressed in the file.
+	Scenario struct {
+		Version [4]byte // e.g. "1.21"
+		Header  Header
+
+		Data       DataHeader
+		Messages   Messages
+		Cinematics Cinematics
+		Bitmap     Bitmap
+		Players    Players
+		Victory    GlobalVictory
+		Diplomacy  Diplomacy
+		Disables   Disables `bin:"if=$Version>=118"`
+		Map        Map
+		Units      Units
+		Settings   PlayerSettings
+		Triggers   Triggers      `bin:"if=$Version>=118"`
+		Files      IncludedFiles `bin:"if=$Version>=118"`
+	}
+
+	IncludedFile struct {
+		Name    String32
+		Content String32
+	}
+
+	// IncludedFiles are AI scripts and other files embedded in the
+	// scenario.
+	IncludedFiles struct {
+		HasFiles  uint32
+		HasESData uint32
+		ESData    [396]byte      `bin:"if=HasESData!=0"`
+		FileCount uint32         `bin:"if=HasFiles!=0"`
+		Files     []IncludedFile `bin:"len=FileCount,if=HasFiles!=0"`
+	}
+)
+
+// String returns the string up to the first NUL byte.
+func (s String16) String() string {
+	return cstring(s.Value)
+}
+
+// String returns the string up to the first NUL byte.
+func (s String32) String() string {
+	return cstring(s.Value)
+}
+
+// cstring returns the string up to the first NUL byte.
+func cstring(s string) string {
+	if i := bytes.IndexByte([]byte(s), 0); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// parseVersion returns the version string multiplied by 100, e.g. 121 for
+// "1.21".
+func parseVersion(b [4]byte) (int, error) {
+	f, err := strconv.ParseFloat(cstring(string(b[:])), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedVersion, b[:])
+	}
+	v := int(f*100 + 0.5)
+	if v < minVersion || v > maxVersion {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedVersion, b[:])
+	}
+	return v, nil
+}
+
+// config returns the codec configuration for the version, the version is
+// available as $Version.
+func config(version int) codec.Config {
+	return codec.Config{Vars: map[string]int{"Version": version}}
+}
+
+// DecodeHeader reads the version and the uncompressed header.
+func DecodeHeader(r io.Reader) (version [4]byte, h *Header, err error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return version, nil, fmt.Errorf("scenario: failed to read version: %w", err)
+	}
+	copy(version[:], head[:4])
+	v, err := parseVersion(version)
+	if err != nil {
+		return version, nil, err
+	}
+	size := binary.LittleEndian.Uint32(head[4:])
+	if size > 1<<20 {
+		return version, nil, fmt.Errorf("%w: length %d", ErrInvalidHeader, size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return version, nil, fmt.Errorf("scenario: failed to read header: %w", err)
+	}
+	h = new(Header)
+	if err := codec.NewDecoder(bytes.NewReader(buf), config(v)).Decode(h); err != nil {
+		return version, nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+	return version, h, nil
+}
+
+// Decode reads a scenario. ErrUnsupportedVersion is returned for versions
+// other than 1.10 to 1.29.
+func Decode(r io.Reader) (*Scenario, error) {
+	br := bufio.NewReader(r)
+	version, h, err := DecodeHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	v, _ := parseVersion(version)
+
+	s := &Scenario{Version: version, Header: *h}
+	d := codec.NewDecoder(bufio.NewReader(flate.NewReader(br)), config(v))
+	if err := d.DecodeFrom(s, 2); err != nil {
+		return nil, fmt.Errorf("scenario: %w", err)
+	}
+	return s, nil
+}
+
+// Open reads the scenario file filename.
+func Open(filename string) (*Scenario, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return Decode(fh)
+}
+
+// VersionString returns the version, e.g. "1.21".
+func (s *Scenario) VersionString() string {
+	return cstring(string(s.Version[:]))
+}
+
+// resolve returns the language string id or text if the string does not
+// exist.
+func resolve(r lang.Resolver, id int32, text string) string {
+	if r != nil && id > 0 {
+		if s, found := r.Get(uint32(id)); found {
+			return s
+		}
+	}
+	return text
+}
+
+// PlayerName returns the name of player (0 to 15) from the language strings.
+// It falls back to the name stored in the scenario.
+func (s *Scenario) PlayerName(player int, r lang.Resolver) string {
+	if player < 0 || player >= MaxPlayers {
+		return ""
+	}
+	name := cstring(string(s.Data.PlayerNames[player][:]))
+	return resolve(r, s.Data.PlayerNameStringIDs[player], name)
+}
+
+// Message returns a message from the language strings. It falls back to the
+// text stored in the scenario.
+func (s *Scenario) Message(kind MessageKind, r lang.Resolver) string {
+	m := &s.Messages
+	switch kind {
+	case MessageInstructions:
+		return resolve(r, m.InstructionsID, m.Instructions.String())
+	case MessageHints:
+		return resolve(r, m.HintsID, m.Hints.String())
+	case MessageVictory:
+		return resolve(r, m.VictoryID, m.Victory.String())
+	case MessageLoss:
+		return resolve(r, m.LossID, m.Loss.String())
+	case MessageHistory:
+		return resolve(r, m.HistoryID, m.History.String())
+	case MessageScouts:
+		return resolve(r, m.ScoutsID, m.Scouts.String())
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package scenario_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/lang"
+	"gopkg.in/KlemensWinter/go-genie.v1/scenario"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/hastings.scx is a small synthetic 1.21 scenario: a 4x3 map, two
+// units of player 1 and a trigger moving them after 60 seconds.
+
+func TestOpen(t *testing.T) {
+	s, err := scenario.Open("./testdata/hastings.scx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1.21", s.VersionString())
+	assert.Equal(t, int32(2), s.Header.SaveVersion)
+	assert.Equal(t, "Defend the castle.", s.Header.Instructions.String())
+	assert.Equal(t, uint32(2), s.Header.PlayerCount)
+
+	assert.Equal(t, float32(1.22), s.Data.Version)
+	assert.Equal(t, "hastings.scx", s.Data.FileName.String())
+	assert.Equal(t, uint32(2), s.Data.PlayerInfos[1].Civ)
+	assert.Equal(t, "You won.", s.Messages.Victory.String())
+	assert.Nil(t, s.Bitmap.Info)
+	assert.Equal(t, uint32(300), s.Players.Resources[0].Food)
+	assert.Equal(t, uint32(1), s.Victory.Conquest)
+	assert.Equal(t, uint32(scenario.StanceEnemy), s.Diplomacy.Stances[0][1])
+
+	techs, units, _ := s.Disables.Disabled(0)
+	assert.Equal(t, []int32{22}, techs)
+	assert.Empty(t, units)
+
+	assert.Equal(t, uint32(4), s.Map.Width)
+	assert.Len(t, s.Map.Rows, 3)
+	if tile := s.Map.Tile(3, 2); assert.NotNil(t, tile) {
+		assert.Equal(t, scenario.Tile{Terrain: 3, Elevation: 2}, *tile)
+	}
+	assert.Nil(t, s.Map.Tile(4, 0))
+
+	assert.Len(t, s.Units.Players, 9)
+	assert.Equal(t, float32(300), s.Units.Resources[0].Food)
+	assert.Empty(t, s.Units.Units(0))
+	if units := s.Units.Units(1); assert.Len(t, units, 2) {
+		assert.Equal(t, uint16(83), units[0].Type)
+		assert.Equal(t, float32(2.5), units[0].Y)
+		assert.Equal(t, int32(-1), units[0].GarrisonedIn)
+	}
+	if u := s.Units.Unit(2); assert.NotNil(t, u) {
+		assert.Equal(t, uint16(82), u.Type)
+	}
+	assert.Len(t, s.Settings.Players, scenario.NumPlayers)
+	assert.Equal(t, uint32(7), s.Settings.Players[7].Color)
+
+	assert.Equal(t, 1.6, s.Triggers.Version)
+	if assert.Len(t, s.Triggers.Triggers, 1) {
+		tr := s.Triggers.Triggers[0]
+		assert.Equal(t, "Reinforcements", tr.Name.String())
+		if assert.Len(t, tr.Conditions, 1) {
+			assert.Equal(t, int32(60), tr.Conditions[0].Field(scenario.ConditionFieldTimer))
+			assert.Equal(t, int32(-1), tr.Conditions[0].Field(100))
+		}
+		if assert.Len(t, tr.Effects, 1) {
+			e := tr.Effects[0]
+			assert.Equal(t, []int32{1, 2}, e.SelectedUnits)
+			assert.Equal(t, int32(3), e.Field(scenario.EffectFieldLocationX))
+		}
+	}
+	assert.Empty(t, s.Files.Files)
+}
+
+func TestMessage(t *testing.T) {
+	s, err := scenario.Open("./testdata/hastings.scx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	strs := lang.Table{10000: "Hold the castle.", 10001: "Harold Godwinson"}
+	assert.Equal(t, "Hold the castle.", s.Message(scenario.MessageInstructions, strs))
+	assert.Equal(t, "Defend the castle.", s.Message(scenario.MessageInstructions, nil))
+	assert.Equal(t, "You won.", s.Message(scenario.MessageVictory, strs))
+	assert.Equal(t, "", s.Message(scenario.MessageScouts, strs))
+
+	assert.Equal(t, "William", s.PlayerName(0, strs))
+	assert.Equal(t, "Harold Godwinson", s.PlayerName(1, strs))
+	assert.Equal(t, "Harold", s.PlayerName(1, nil))
+	assert.Equal(t, "", s.PlayerName(16, strs))
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data, err := os.ReadFile("./testdata/hastings.scx")
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, n := range []int{0, 6, 20, len(data) - 10} {
+		_, err := scenario.Decode(bytes.NewReader(data[:n]))
+		assert.Error(t, err, "%d bytes", n)
+	}
+}
+
+// hdHeader returns the header of an HD scenario.
+func hdHeader() []byte {
+	var h bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&h, le, int32(3))     // save version
+	binary.Write(&h, le, uint32(0))    // timestamp
+	binary.Write(&h, le, uint32(0))    // instructions
+	binary.Write(&h, le, uint32(0))    // unused
+	binary.Write(&h, le, uint32(2))    // players
+	binary.Write(&h, le, uint32(1000)) // unknown
+	binary.Write(&h, le, uint32(2))    // game edition
+	binary.Write(&h, le, uint32(1))    // datasets
+	binary.Write(&h, le, uint32(100))  // dataset
+
+	var b bytes.Buffer
+	b.WriteString("1.26")
+	binary.Write(&b, le, uint32(h.Len()))
+	b.Write(h.Bytes())
+	return b.Bytes()
+}
+
+func TestDecodeHeader(t *testing.T) {
+	version, h, err := scenario.DecodeHeader(bytes.NewReader(hdHeader()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "1.26", string(version[:]))
+	assert.Equal(t, []uint32{100}, h.Datasets)
+
+	// DE scenarios are not supported
+	for _, v := range []string{"1.00", "1.30", "1.47", "2.00", "abcd"} {
+		data := hdHeader()
+		copy(data, v)
+		_, _, err := scenario.DecodeHeader(bytes.NewReader(data))
+		assert.ErrorIs(t, err, scenario.ErrUnsupportedVersion, v)
+	}
+
+	_, _, err = scenario.DecodeHeader(bytes.NewReader(hdHeader()[:30]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,178 @@
+package scenario
+
+// Effect types of AoC.
+const (
+	EffectNone EffectType = iota
+	EffectChangeDiplomacy
+	EffectResearchTechnology
+	EffectSendChat
+	EffectPlaySound
+	EffectSendTribute
+	EffectUnlockGate
+	EffectLockGate
+	EffectActivateTrigger
+	EffectDeactivateTrigger
+	EffectAIScriptGoal
+	EffectCreateObject
+	EffectTaskObject
+	EffectDeclareVictory
+	EffectKillObject
+	EffectRemoveObject
+	EffectChangeView
+	EffectUnload
+	EffectChangeOwnership
+	EffectPatrol
+	EffectDisplayInstructions
+	EffectClearInstructions
+	EffectFreezeUnit
+	EffectUseAdvancedButtons
+	EffectDamageObject
+	EffectPlaceFoundation
+	EffectChangeObjectName
+	EffectChangeObjectHP
+	EffectChangeObjectAttack
+	EffectStopUnit
+)
+
+// Condition types of AoC.
+const (
+	ConditionNone ConditionType = iota
+	ConditionBringObjectToArea
+	ConditionBringObjectToObject
+	ConditionOwnObjects
+	ConditionOwnFewerObjects
+	ConditionObjectsInArea
+	ConditionDestroyObject
+	ConditionCaptureObject
+	ConditionAccumulateAttribute
+	ConditionResearchTechnology
+	ConditionTimer
+	ConditionObjectSelected
+	ConditionAISignal
+	ConditionPlayerDefeated
+	ConditionObjectHasTarget
+	ConditionObjectVisible
+	ConditionObjectNotVisible
+	ConditionResearchingTechnology
+	ConditionUnitsGarrisoned
+	ConditionDifficultyLevel
+)
+
+// Indices of the effect fields.
+const (
+	EffectFieldAIGoal = iota
+	EffectFieldAmount
+	EffectFieldResource
+	EffectFieldDiplomacy
+	EffectFieldSelectedCount
+	EffectFieldLocationUnit
+	EffectFieldUnitType
+	EffectFieldPlayerSource
+	EffectFieldPlayerTarget
+	EffectFieldTechnology
+	EffectFieldStringID
+	EffectFieldSoundID
+	EffectFieldDisplayTime
+	EffectFieldTriggerID
+	EffectFieldLocationX
+	EffectFieldLocationY
+	EffectFieldAreaX1
+	EffectFieldAreaY1
+	EffectFieldAreaX2
+	EffectFieldAreaY2
+	EffectFieldUnitGroup
+	EffectFieldUnitClass
+	EffectFieldInstructionPanel
+
+	NumEffectFields // of AoC
+)
+
+// Indices of the condition fields.
+const (
+	ConditionFieldAmount = iota
+	ConditionFieldResource
+	ConditionFieldUnitObject
+	ConditionFieldUnitLocation
+	ConditionFieldUnitType
+	ConditionFieldPlayer
+	ConditionFieldTechnology
+	ConditionFieldTimer
+	ConditionFieldUnknown
+	ConditionFieldAreaX1
+	ConditionFieldAreaY1
+	ConditionFieldAreaX2
+	ConditionFieldAreaY2
+	ConditionFieldUnitGroup
+	ConditionFieldUnitClass
+	ConditionFieldAISignal
+
+	NumConditionFields // of AoC
+)
+
+type (
+	EffectType    int32
+	ConditionType int32
+
+	// Effect is executed when the conditions of a trigger are met. The
+	// meaning of the fields depends on the type, see the EffectField
+	// constants for the indices.
+	Effect struct {
+		Type          EffectType
+		FieldCount    int32
+		Fields        []int32 `bin:"len=FieldCount"`
+		Text          String32
+		Sound         String32
+		SelectedUnits []int32 `bin:"len=Fields[4]"` // unit IDs
+	}
+
+	// Condition is a condition of a trigger, see the ConditionField
+	// constants for the indices of the fields.
+	Condition struct {
+		Type       ConditionType
+		FieldCount int32
+		Fields     []int32 `bin:"len=FieldCount"`
+	}
+
+	Trigger struct {
+		Enabled        uint32
+		Looping        int8
+		NameStringID   int32 `bin:"if=$TriggerVersion>=180"`
+		IsObjective    uint8
+		ObjectiveOrder uint32
+		StartingTime   uint32
+		Description    String32
+		Name           String32
+
+		EffectCount    int32
+		Effects        []Effect `bin:"len=EffectCount"`
+		EffectOrder    []int32  `bin:"len=EffectCount"`
+		ConditionCount int32
+		Conditions     []Condition `bin:"len=ConditionCount"`
+		ConditionOrder []int32     `bin:"len=ConditionCount"`
+	}
+
+	Triggers struct {
+		Version         float64 `bin:"var=TriggerVersion"` // 1.6 for AoC
+		ObjectivesState int8    `bin:"if=Version>=150"`
+		TriggerCount    int32
+		Triggers        []Trigger `bin:"len=TriggerCount"`
+		DisplayOrder    []int32   `bin:"len=TriggerCount,if=Version>=140"`
+	}
+)
+
+// Field returns a field of the effect or -1 if the effect does not have it.
+func (e *Effect) Field(i int) int32 {
+	if i < 0 || i >= len(e.Fields) {
+		return -1
+	}
+	return e.Fields[i]
+}
+
+// Field returns a field of the condition or -1 if the condition does not
+// have it.
+func (c *Condition) Field(i int) int32 {
+	if i < 0 || i >= len(c.Fields) {
+		return -1
+	}
+	return c.Fields[i]
+}
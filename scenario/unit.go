@@ -0,0 +1,58 @@
+package scenario
+
+type (
+	// Unit is a unit placed on the map.
+	Unit struct {
+		X            float32
+		Y            float32
+		Z            float32
+		ID           uint32
+		Type         uint16 // unit ID of the DAT file
+		Status       uint8  // 2
+		Rotation     float32
+		Frame        uint16 `bin:"if=$Version>=118"`
+		GarrisonedIn int32  `bin:"if=$Version>=118"` // ID of the unit or -1
+	}
+
+	UnitList struct {
+		UnitCount uint32
+		Units     []Unit `bin:"len=UnitCount"`
+	}
+
+	// StartResources are the resources of a player when the game starts.
+	StartResources struct {
+		Food     float32
+		Wood     float32
+		Gold     float32
+		Stone    float32
+		OreX     float32
+		Unused   float32
+		PopLimit float32 `bin:"if=$Version>=122"`
+	}
+
+	Units struct {
+		PlayerCount uint32 // including gaia
+		Resources   [NumPlayers]StartResources
+		Players     []UnitList `bin:"len=PlayerCount"` // gaia first
+	}
+)
+
+// Units returns the units of player, 0 is gaia.
+func (u *Units) Units(player int) []Unit {
+	if player < 0 || player >= len(u.Players) {
+		return nil
+	}
+	return u.Players[player].Units
+}
+
+// Unit returns the unit with the given ID or nil.
+func (u *Units) Unit(id uint32) *Unit {
+	for i := range u.Players {
+		for j := range u.Players[i].Units {
+			if u.Players[i].Units[j].ID == id {
+				return &u.Players[i].Units[j]
+			}
+		}
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"image"
 	"image/color"
 	"io"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
 )
 
 type DrawFlags int
@@ -77,7 +79,7 @@ mainloop:
 			case CMD_PLAYER_COLOR_DRAW:
 				count := r.rshiftOrNext(cmd_byte, 4)
 				for i := 0; i < count; i++ {
-					setPix(x, y, r.getc()+byte(playerID*16))
+					setPix(x, y, palette.DefaultPlayerColors.Remap(r.getc(), playerID))
 					x++
 				}
 			case CMD_FILL:
@@ -89,7 +91,7 @@ mainloop:
 				}
 			case CMD_FILL_PLAYER_COLOR:
 				n := r.rshiftOrNext(cmd_byte, 4)
-				col := r.getc() + byte(playerID*16)
+				col := palette.DefaultPlayerColors.Remap(r.getc(), playerID)
 				for i := 0; i < n; i++ {
 					setPix(x, y, col)
 					x++
@@ -181,3 +183,15 @@ func DrawTo(img *image.RGBA, pal color.Palette, f *Frame, playerID int, flags Dr
 	}
 	return drawTo(img, pal, data, f, playerID, flags)
 }
+
+// Palettes provides palettes by ID, see palette.Set.
+type Palettes interface {
+	Lookup(id int) color.Palette
+}
+
+// DrawFrame draws the frame with the palette it references. The palette ID
+// is passed to pals unchanged, so ID 0 is the palette 0 of palettes.conf;
+// pals decides on a fallback for missing palettes (see palette.Set.Lookup).
+func DrawFrame(img *image.RGBA, pals Palettes, f *Frame, playerID int, flags DrawFlags) error {
+	return DrawTo(img, pals.Lookup(f.PaletteID()), f, playerID, flags)
+}
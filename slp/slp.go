@@ -86,3 +86,9 @@ func (rc *ReadCloser) Close() error {
 func (f *Frame) Bounds() image.Rectangle {
 	return image.Rect(0, 0, int(f.Width), int(f.Height))
 }
+
+// PaletteID returns the number of the palette the frame is drawn with, as
+// stored in the upper bits of the properties. Zero means the default palette.
+func (fi FrameInfo) PaletteID() int {
+	return int(fi.Properties >> 16)
+}
@@ -0,0 +1,60 @@
+package slp_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"gopkg.in/KlemensWinter/go-genie.v1/internal/testutil"
+	"gopkg.in/KlemensWinter/go-genie.v1/palette"
+	"gopkg.in/KlemensWinter/go-genie.v1/slp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// palettes records the IDs it is asked for.
+type palettes struct {
+	ids []int
+}
+
+func (p *palettes) Lookup(id int) color.Palette {
+	p.ids = append(p.ids, id)
+	return palette.Default
+}
+
+func newReader(t *testing.T, frames ...testutil.Frame) *slp.Reader {
+	data := testutil.SLP(frames...)
+	rd, err := slp.New(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rd
+}
+
+func TestPaletteID(t *testing.T) {
+	rd := newReader(t,
+		testutil.Frame{Rows: [][]byte{{1}}},
+		testutil.Frame{Rows: [][]byte{{1}}, Properties: 50532<<16 | 0x18},
+	)
+	assert.Equal(t, 0, rd.Frames[0].PaletteID())
+	assert.Equal(t, 50532, rd.Frames[1].PaletteID())
+}
+
+func TestDrawFrame(t *testing.T) {
+	rd := newReader(t,
+		testutil.Frame{Rows: [][]byte{{5, 6}, {7}}, Left: []int{0, 1}},
+		testutil.Frame{Rows: [][]byte{{5}}, Properties: 3 << 16},
+	)
+	pals := &palettes{}
+
+	f := rd.Frames[0]
+	img := image.NewRGBA(f.Bounds())
+	if assert.NoError(t, slp.DrawFrame(img, pals, f, 0, 0)) {
+		assert.Equal(t, color.RGBAModel.Convert(palette.Default[6]), img.RGBAAt(1, 0))
+		assert.Equal(t, color.RGBA{}, img.RGBAAt(0, 1))
+		assert.Equal(t, color.RGBAModel.Convert(palette.Default[7]), img.RGBAAt(1, 1))
+	}
+	assert.NoError(t, slp.DrawFrame(image.NewRGBA(f.Bounds()), pals, rd.Frames[1], 0, 0))
+	assert.Equal(t, []int{0, 3}, pals.ids)
+}